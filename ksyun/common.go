@@ -18,7 +18,8 @@ import (
 type Status string
 
 const (
-	Deleted = Status("Deleted")
+	Available = Status("Available")
+	Deleted   = Status("Deleted")
 )
 
 const DefaultTimeoutMedium = 500
@@ -240,3 +241,13 @@ func ParseResourceId(id string, length int) (parts []string, err error) {
 	}
 	return parts, err
 }
+
+func expandStringList(configured []interface{}) []string {
+	result := make([]string, 0, len(configured))
+	for _, v := range configured {
+		if v, ok := v.(string); ok && v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -66,7 +66,9 @@ func (client *KsyunClient) WithKs3Client(do func(*ks3.Client) (interface{}, erro
 	defer goSdkMutex.Unlock()
 	// Initialize the KS3 client if necessary
 	if client.ks3conn == nil {
-		ks3conn, err := ks3.New(client.Endpoint, client.AccessKey, client.SecretKey)
+		// Every request body is sent with its Content-MD5, so KS3 rejects uploads corrupted in transit.
+		// The SDK leaves a Content-MD5 that is already set alone.
+		ks3conn, err := ks3.New(client.Endpoint, client.AccessKey, client.SecretKey, ks3.EnableMD5(true))
 		if err != nil {
 			return nil, fmt.Errorf("unable to initialize the KS3 client: %#v", err)
 		}
@@ -1,5 +1,9 @@
 package connectivity
 
+import (
+	"strings"
+)
+
 // ServiceCode Load endpoints from endpoints.xml or environment variables to meet specified application scenario, like private cloud.
 type ServiceCode string
 
@@ -25,3 +29,30 @@ type Product struct {
 	ProductName string `xml:"ProductName"`
 	DomainName  string `xml:"DomainName"`
 }
+
+// Ks3Endpoint holds the public and the internal domain of the KS3 service in one region.
+type Ks3Endpoint struct {
+	Extranet string
+	Intranet string
+}
+
+// Ks3Endpoints is the catalog of the KS3 endpoints by region.
+var Ks3Endpoints = map[Region]Ks3Endpoint{
+	BEIJING:   {Extranet: "ks3-cn-beijing.ksyuncs.com", Intranet: "ks3-cn-beijing-internal.ksyuncs.com"},
+	SHANGHAI:  {Extranet: "ks3-cn-shanghai.ksyuncs.com", Intranet: "ks3-cn-shanghai-internal.ksyuncs.com"},
+	GUANGZHOU: {Extranet: "ks3-cn-guangzhou.ksyuncs.com", Intranet: "ks3-cn-guangzhou-internal.ksyuncs.com"},
+	HONGKONG:  {Extranet: "ks3-cn-hk-1.ksyuncs.com", Intranet: "ks3-cn-hk-1-internal.ksyuncs.com"},
+}
+
+// Ks3EndpointByRegion returns the KS3 endpoints of the region. Regions missing from the catalog,
+// such as private deployments, fall back to the domain of the endpoint configured on the provider.
+func (client *KsyunClient) Ks3EndpointByRegion(region Region) Ks3Endpoint {
+	if endpoint, ok := Ks3Endpoints[Region(strings.ToUpper(string(region)))]; ok {
+		return endpoint
+	}
+	endpoint := client.Endpoint
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		endpoint = endpoint[i+len("://"):]
+	}
+	return Ks3Endpoint{Extranet: strings.TrimSuffix(endpoint, "/")}
+}
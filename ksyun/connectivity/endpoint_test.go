@@ -0,0 +1,25 @@
+package connectivity
+
+import (
+	"testing"
+)
+
+func TestKs3EndpointByRegion(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		region   Region
+		expected Ks3Endpoint
+	}{
+		{"", "beijing", Ks3Endpoints[BEIJING]},
+		{"https://ks3.example.com", "SHANGHAI", Ks3Endpoints[SHANGHAI]},
+		{"ks3.example.com", "PRIVATE", Ks3Endpoint{Extranet: "ks3.example.com"}},
+		{"https://ks3.example.com/", "PRIVATE", Ks3Endpoint{Extranet: "ks3.example.com"}},
+		{"http://10.0.0.1:8080", "PRIVATE", Ks3Endpoint{Extranet: "10.0.0.1:8080"}},
+	}
+	for _, c := range cases {
+		client := &KsyunClient{Endpoint: c.endpoint}
+		if got := client.Ks3EndpointByRegion(c.region); got != c.expected {
+			t.Errorf("Ks3EndpointByRegion(%q) with the endpoint %q = %+v, expected %+v", c.region, c.endpoint, got, c.expected)
+		}
+	}
+}
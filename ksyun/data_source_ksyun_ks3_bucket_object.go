@@ -0,0 +1,216 @@
+package ksyun
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The body of an object is only returned up to this size, larger objects would bloat the state.
+const ks3ObjectBodyMaxSize = 1024 * 1024
+
+func dataSourceKsyunKs3BucketObject() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKsyunKs3BucketObjectRead,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"version_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"sse_customer_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ValidateFunc: validateKs3SSECustomerKey,
+			},
+			"range": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^(bytes=)?(\d+-\d*|-\d+)$`), "must be a byte range such as \"0-1023\""),
+			},
+
+			// Computed values
+			"body": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_length": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"cache_control": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_disposition": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_encoding": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"content_language": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expires": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_modified": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"etag": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"metadata": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"storage_class": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"server_side_encryption": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"kms_key_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceKsyunKs3BucketObjectRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+	id := ks3BucketObjectId(bucketName, key)
+
+	var options []ks3.Option
+	if v, ok := d.GetOk("version_id"); ok {
+		options = append(options, ks3.VersionId(v.(string)))
+	}
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = append(options, ks3SSECOptions(v.(string))...)
+	}
+
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectDetailedMeta(key, options...)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, id, "GetObjectDetailedMeta", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectDetailedMeta", raw, requestInfo, map[string]interface{}{
+		"objectKey": key,
+		"options":   options,
+	})
+	object, _ := raw.(http.Header)
+
+	d.SetId(id)
+	d.Set("content_type", object.Get("Content-Type"))
+	d.Set("content_length", object.Get("Content-Length"))
+	d.Set("cache_control", object.Get("Cache-Control"))
+	d.Set("content_disposition", object.Get("Content-Disposition"))
+	d.Set("content_encoding", object.Get("Content-Encoding"))
+	d.Set("content_language", object.Get("Content-Language"))
+	d.Set("expires", object.Get("Expires"))
+	d.Set("last_modified", object.Get(ks3.HTTPHeaderLastModified))
+	d.Set("etag", strings.Trim(object.Get("ETag"), `"`))
+	d.Set("version_id", object.Get("X-Kss-Version-Id"))
+	d.Set("server_side_encryption", object.Get(ks3.HTTPHeaderKs3ServerSideEncryption))
+	d.Set("kms_key_id", object.Get(ks3.HTTPHeaderKs3ServerSideEncryptionKeyID))
+	if storageClass := object.Get(ks3.HTTPHeaderKs3StorageClass); storageClass != "" {
+		d.Set("storage_class", storageClass)
+	} else {
+		d.Set("storage_class", string(ks3.StorageStandard))
+	}
+	if err := d.Set("metadata", ks3ObjectMetadata(object)); err != nil {
+		return WrapError(err)
+	}
+
+	contentType := object.Get("Content-Type")
+	if !isKs3TextContentType(contentType) {
+		log.Printf("[INFO] Ignoring the body of the Ks3 object %s, its content type %q is not text", id, contentType)
+		d.Set("body", "")
+		return nil
+	}
+	_, ranged := d.GetOk("range")
+	if size, err := strconv.ParseInt(object.Get("Content-Length"), 10, 64); err == nil && size > ks3ObjectBodyMaxSize && !ranged {
+		log.Printf("[INFO] Ignoring the body of the Ks3 object %s, its size %d exceeds %d bytes", id, size, ks3ObjectBodyMaxSize)
+		d.Set("body", "")
+		return nil
+	}
+
+	if v, ok := d.GetOk("range"); ok {
+		options = append(options, ks3.NormalizedRange(strings.TrimPrefix(v.(string), "bytes=")))
+	}
+	raw, err = client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		body, err := bucket.GetObject(key, options...)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(io.LimitReader(body, ks3ObjectBodyMaxSize+1))
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, id, "GetObject", KsyunKs3GoSdk)
+	}
+	body, _ := raw.([]byte)
+	if len(body) > ks3ObjectBodyMaxSize {
+		log.Printf("[INFO] Ignoring the body of the Ks3 object %s, the requested range exceeds %d bytes", id, ks3ObjectBodyMaxSize)
+		body = nil
+	}
+	d.Set("body", string(body))
+	return nil
+}
+
+// isKs3TextContentType reports whether the body of an object with the content type can be stored as a
+// string: text types and the common structured text formats.
+func isKs3TextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-javascript",
+		"application/yaml", "application/x-yaml", "application/x-www-form-urlencoded", "application/toml":
+		return true
+	}
+	return false
+}
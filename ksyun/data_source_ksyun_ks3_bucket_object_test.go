@@ -0,0 +1,21 @@
+package ksyun
+
+import "testing"
+
+func TestIsKs3TextContentType(t *testing.T) {
+	cases := map[string]bool{
+		"text/plain":                     true,
+		"text/html; charset=utf-8":       true,
+		"application/json":               true,
+		"application/vnd.api+json":       true,
+		"application/xml; charset=UTF-8": true,
+		"application/octet-stream":       false,
+		"image/png":                      false,
+		"":                               false,
+	}
+	for contentType, expected := range cases {
+		if got := isKs3TextContentType(contentType); got != expected {
+			t.Errorf("isKs3TextContentType(%q) = %t, expected %t", contentType, got, expected)
+		}
+	}
+}
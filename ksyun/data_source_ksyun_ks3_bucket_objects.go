@@ -9,9 +9,13 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
+// The number of objects whose details are fetched in parallel.
+const ks3ObjectsDescribeRoutines = 8
+
 func dataSourceKsyunKs3BucketObjects() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceKsyunKs3BucketObjectsRead,
@@ -37,6 +41,27 @@ func dataSourceKsyunKs3BucketObjects() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"include_metadata": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"include_acl": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			// The tags of every object were always fetched as well, so they can be skipped the same way.
+			"include_tags": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"max_items": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
 
 			// Computed values
 			"objects": {
@@ -88,6 +113,16 @@ func dataSourceKsyunKs3BucketObjects() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"metadata": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"tags": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
 						"etag": {
 							Type:     schema.TypeString,
 							Computed: true,
@@ -107,10 +142,34 @@ func dataSourceKsyunKs3BucketObjects() *schema.Resource {
 	}
 }
 
+// ks3ObjectsReader is the part of *ks3.Bucket the objects are listed and described with.
+type ks3ObjectsReader interface {
+	ListObjects(options ...ks3.Option) (ks3.ListObjectsResult, error)
+	GetObjectDetailedMeta(objectKey string, options ...ks3.Option) (http.Header, error)
+	GetObjectACL(objectKey string, options ...ks3.Option) (ks3.GetObjectACLResult, error)
+	GetObjectTagging(objectKey string, options ...ks3.Option) (ks3.GetObjectTaggingResult, error)
+}
+
 func dataSourceKsyunKs3BucketObjectsRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*connectivity.KsyunClient)
+	raw, err := client.WithKs3BucketByName(d.Get("bucket_name").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		return bucket, nil
+	})
+	if err != nil {
+		return WrapErrorf(err, DataDefaultErrorMsg, "ksyun_ks3_bucket_object", "Bucket", KsyunKs3GoSdk)
+	}
+	bucket, _ := raw.(*ks3.Bucket)
+	return readKs3BucketObjects(d, bucket, &bucket.Client)
+}
 
+func readKs3BucketObjects(d *schema.ResourceData, bucket ks3ObjectsReader, requestInfo *ks3.Client) error {
 	bucketName := d.Get("bucket_name").(string)
+	maxItems := d.Get("max_items").(int)
+
+	var keyRegex *regexp.Regexp
+	if v, ok := d.GetOk("key_regex"); ok && v.(string) != "" {
+		keyRegex = regexp.MustCompile(v.(string))
+	}
 
 	// List bucket objects
 	var initialOptions []ks3.Option
@@ -118,7 +177,6 @@ func dataSourceKsyunKs3BucketObjectsRead(d *schema.ResourceData, meta interface{
 		keyPrefix := v.(string)
 		initialOptions = append(initialOptions, ks3.Prefix(keyPrefix))
 	}
-	var requestInfo *ks3.Client
 	var allObjects []ks3.ObjectProperties
 	nextMarker := ""
 	for {
@@ -128,76 +186,99 @@ func dataSourceKsyunKs3BucketObjectsRead(d *schema.ResourceData, meta interface{
 			options = append(options, ks3.Marker(nextMarker))
 		}
 
-		raw, err := client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
-			requestInfo = &bucket.Client
-			return bucket.ListObjects(options...)
-		})
+		response, err := bucket.ListObjects(options...)
 		if err != nil {
 			return WrapErrorf(err, DataDefaultErrorMsg, "ksyun_ks3_bucket_object", "ListObjects", KsyunKs3GoSdk)
 		}
 		if debugOn() {
-			addDebug("ListObjects", raw, requestInfo, map[string]interface{}{"options": options})
-		}
-		response, _ := raw.(ks3.ListObjectsResult)
-
-		if response.Objects == nil || len(response.Objects) < 1 {
-			break
+			addDebug("ListObjects", response, requestInfo, map[string]interface{}{"options": options})
 		}
 
 		if len(response.Objects) < 1 {
 			break
 		}
 
-		allObjects = append(allObjects, response.Objects...)
-
-		nextMarker = response.NextMarker
-		if nextMarker == "" {
+		for _, object := range response.Objects {
+			if keyRegex != nil && !keyRegex.MatchString(object.Key) {
+				continue
+			}
+			allObjects = append(allObjects, object)
+		}
+		if maxItems > 0 && len(allObjects) >= maxItems {
+			log.Printf("[WARN] Listing of the Ks3 bucket %s stopped after max_items (%d) objects", bucketName, maxItems)
+			allObjects = allObjects[:maxItems]
 			break
 		}
-	}
 
-	var filteredObjectsTemp []ks3.ObjectProperties
-	keyRegex, ok := d.GetOk("key_regex")
-	if ok && keyRegex.(string) != "" {
-		var r *regexp.Regexp
-		if keyRegex != "" {
-			r = regexp.MustCompile(keyRegex.(string))
+		if !response.IsTruncated {
+			break
 		}
-		for _, object := range allObjects {
-			if r != nil && !r.MatchString(object.Key) {
-				continue
-			}
-			filteredObjectsTemp = append(filteredObjectsTemp, object)
+		// KS3 only returns the next marker when a delimiter is set.
+		nextMarker = response.NextMarker
+		if nextMarker == "" {
+			nextMarker = response.Objects[len(response.Objects)-1].Key
 		}
-	} else {
-		filteredObjectsTemp = allObjects
 	}
 
-	return bucketObjectsDescriptionAttributes(d, bucketName, filteredObjectsTemp, meta)
+	return bucketObjectsDescriptionAttributes(d, bucket, requestInfo, allObjects)
 }
 
-func bucketObjectsDescriptionAttributes(d *schema.ResourceData, bucketName string, objects []ks3.ObjectProperties, meta interface{}) error {
-	client := meta.(*connectivity.KsyunClient)
+func bucketObjectsDescriptionAttributes(d *schema.ResourceData, bucket ks3ObjectsReader, requestInfo *ks3.Client, objects []ks3.ObjectProperties) error {
 	var ids []string
-	var s []map[string]interface{}
-	var requestInfo *ks3.Client
+	s := make([]map[string]interface{}, 0, len(objects))
 	for _, object := range objects {
-		mapping := map[string]interface{}{
+		ids = append(ids, object.Key)
+		s = append(s, map[string]interface{}{
 			"key":                    object.Key,
 			"etag":                   strings.Trim(object.ETag, `"`),
 			"storage_class":          object.StorageClass,
 			"last_modification_time": object.LastModified.Format(time.RFC3339),
+		})
+	}
+
+	includeMetadata := d.Get("include_metadata").(bool)
+	includeACL := d.Get("include_acl").(bool)
+	includeTags := d.Get("include_tags").(bool)
+	if len(objects) > 0 && (includeMetadata || includeACL || includeTags) {
+		// Every object is described by its own worker, which only writes to the mapping of that object.
+		var wg sync.WaitGroup
+		jobs := make(chan int)
+		for i := 0; i < ks3ObjectsDescribeRoutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for index := range jobs {
+					describeKs3BucketObjectMapping(bucket, requestInfo, s[index], includeMetadata, includeACL, includeTags)
+				}
+			}()
+		}
+		for index := range s {
+			jobs <- index
 		}
+		close(jobs)
+		wg.Wait()
+	}
 
-		// Add metadata information
-		raw, err := client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
-			requestInfo = &bucket.Client
-			return bucket.GetObjectDetailedMeta(object.Key)
-		})
+	d.SetId(dataResourceIdHash(ids))
+	if err := d.Set("objects", s); err != nil {
+		return WrapError(err)
+	}
+	// create a json file in current directory and write data source to it.
+	if output, ok := d.GetOk("output_file"); ok && output.(string) != "" {
+		writeToFile(output.(string), s)
+	}
+	return nil
+}
+
+// describeKs3BucketObjectMapping adds the metadata, the ACL and the tags of the object to its mapping.
+// Failures are logged and leave the attributes empty.
+func describeKs3BucketObjectMapping(bucket ks3ObjectsReader, requestInfo *ks3.Client, mapping map[string]interface{}, includeMetadata, includeACL, includeTags bool) {
+	key := mapping["key"].(string)
+	if includeMetadata {
+		objectHeader, err := bucket.GetObjectDetailedMeta(key)
 		if err != nil {
-			log.Printf("[ERROR] Unable to get metadata for the object %s: %v", object.Key, err)
+			log.Printf("[ERROR] Unable to get metadata for the object %s: %v", key, err)
 		} else {
-			objectHeader, _ := raw.(http.Header)
 			mapping["content_type"] = objectHeader.Get("Content-Type")
 			mapping["content_length"] = objectHeader.Get("Content-Length")
 			mapping["cache_control"] = objectHeader.Get("Cache-Control")
@@ -207,36 +288,35 @@ func bucketObjectsDescriptionAttributes(d *schema.ResourceData, bucketName strin
 			mapping["expires"] = objectHeader.Get("Expires")
 			mapping["server_side_encryption"] = objectHeader.Get(ks3.HTTPHeaderKs3ServerSideEncryption)
 			mapping["sse_kms_key_id"] = objectHeader.Get(ks3.HTTPHeaderKs3ServerSideEncryptionKeyID)
+			mapping["metadata"] = ks3ObjectMetadata(objectHeader)
 		}
 		if debugOn() {
-			addDebug("GetObjectDetailedMeta", raw, requestInfo, map[string]string{"objectKey": object.Key})
+			addDebug("GetObjectDetailedMeta", objectHeader, requestInfo, map[string]string{"objectKey": key})
 		}
-		// Add ACL information
-		raw, err = client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
-			requestInfo = &bucket.Client
-			return bucket.GetObjectACL(object.Key)
-		})
+	}
+
+	if includeACL {
+		objectACL, err := bucket.GetObjectACL(key)
 		if err != nil {
-			log.Printf("[ERROR] Unable to get ACL for the object %s: %v", object.Key, err)
+			log.Printf("[ERROR] Unable to get ACL for the object %s: %v", key, err)
 		} else {
-			objectACL, _ := raw.(ks3.GetObjectACLResult)
-			mapping["acl"] = objectACL.ACL
+			// The grants are reported as the matching canned ACL, or "custom" when no canned ACL matches.
+			mapping["acl"] = ks3CannedACL(objectACL)
 		}
 		if debugOn() {
-			addDebug("GetObjectACL", raw, requestInfo, map[string]string{"objectKey": object.Key})
+			addDebug("GetObjectACL", objectACL, requestInfo, map[string]string{"objectKey": key})
 		}
-
-		ids = append(ids, object.Key)
-		s = append(s, mapping)
 	}
 
-	d.SetId(dataResourceIdHash(ids))
-	if err := d.Set("objects", s); err != nil {
-		return WrapError(err)
-	}
-	// create a json file in current directory and write data source to it.
-	if output, ok := d.GetOk("output_file"); ok && output.(string) != "" {
-		writeToFile(output.(string), s)
+	if includeTags {
+		tagging, err := bucket.GetObjectTagging(key)
+		if err != nil {
+			log.Printf("[ERROR] Unable to get tagging for the object %s: %v", key, err)
+		} else {
+			mapping["tags"] = ks3TagsToMap(tagging.Tags)
+		}
+		if debugOn() {
+			addDebug("GetObjectTagging", tagging, requestInfo, map[string]string{"objectKey": key})
+		}
 	}
-	return nil
 }
@@ -0,0 +1,171 @@
+package ksyun
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+// fakeKs3ObjectsReader answers the listing and the detail requests from memory and counts the requests
+// by operation. With a page size, the listing is truncated like KS3 without a delimiter, with no next
+// marker.
+type fakeKs3ObjectsReader struct {
+	keys     []string
+	pageSize int
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (r *fakeKs3ObjectsReader) record(operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[operation]++
+}
+
+func (r *fakeKs3ObjectsReader) count(operation string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[operation]
+}
+
+func (r *fakeKs3ObjectsReader) ListObjects(options ...ks3.Option) (ks3.ListObjectsResult, error) {
+	r.record("ListObjects")
+	params, err := ks3.GetRawParams(options)
+	if err != nil {
+		return ks3.ListObjectsResult{}, err
+	}
+	keys := r.keys
+	if marker, ok := params["marker"].(string); ok {
+		for i, key := range keys {
+			if key > marker {
+				keys = keys[i:]
+				break
+			}
+			if i == len(keys)-1 {
+				keys = nil
+			}
+		}
+	}
+	var result ks3.ListObjectsResult
+	if r.pageSize > 0 && len(keys) > r.pageSize {
+		keys, result.IsTruncated = keys[:r.pageSize], true
+	}
+	for _, key := range keys {
+		result.Objects = append(result.Objects, ks3.ObjectProperties{
+			Key:          key,
+			ETag:         `"etag-` + key + `"`,
+			LastModified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			StorageClass: "STANDARD",
+		})
+	}
+	return result, nil
+}
+
+func (r *fakeKs3ObjectsReader) GetObjectDetailedMeta(objectKey string, options ...ks3.Option) (http.Header, error) {
+	r.record("GetObjectDetailedMeta")
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain")
+	header.Set("X-Kss-Meta-Owner", "team")
+	return header, nil
+}
+
+func (r *fakeKs3ObjectsReader) GetObjectACL(objectKey string, options ...ks3.Option) (ks3.GetObjectACLResult, error) {
+	r.record("GetObjectACL")
+	var result ks3.GetObjectACLResult
+	result.ACL = []ks3.Grant{{Grantee: ks3.Grantee{Uri: ks3.ALL_USERS}, Permission: ks3.PermissionRead}}
+	return result, nil
+}
+
+func (r *fakeKs3ObjectsReader) GetObjectTagging(objectKey string, options ...ks3.Option) (ks3.GetObjectTaggingResult, error) {
+	r.record("GetObjectTagging")
+	return ks3.GetObjectTaggingResult{Tags: []ks3.Tag{{Key: "env", Value: "test"}}}, nil
+}
+
+func TestReadKs3BucketObjectsMaxItems(t *testing.T) {
+	bucket := &fakeKs3ObjectsReader{keys: []string{"a.txt", "b.txt", "c.txt"}, calls: map[string]int{}}
+	d := schema.TestResourceDataRaw(t, dataSourceKsyunKs3BucketObjects().Schema, map[string]interface{}{
+		"bucket_name":  "my-bucket",
+		"max_items":    2,
+		"include_acl":  false,
+		"include_tags": false,
+	})
+	if err := readKs3BucketObjects(d, bucket, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	objects := d.Get("objects").([]interface{})
+	if len(objects) != 2 {
+		t.Fatalf("expected max_items to keep 2 objects, got %d", len(objects))
+	}
+	for i, key := range []string{"a.txt", "b.txt"} {
+		object := objects[i].(map[string]interface{})
+		if object["key"] != key || object["etag"] != "etag-"+key {
+			t.Errorf("unexpected object %d: %v", i, object)
+		}
+		if object["content_type"] != "text/plain" || object["metadata"].(map[string]interface{})["owner"] != "team" {
+			t.Errorf("object %s is missing its metadata: %v", key, object)
+		}
+		if object["acl"] != "" || len(object["tags"].(map[string]interface{})) != 0 {
+			t.Errorf("object %s has the skipped ACL or tags: %v", key, object)
+		}
+	}
+	if n := bucket.count("GetObjectDetailedMeta"); n != 2 {
+		t.Errorf("expected the metadata of the 2 kept objects to be fetched, got %d requests", n)
+	}
+	if n := bucket.count("GetObjectACL") + bucket.count("GetObjectTagging"); n != 0 {
+		t.Errorf("expected no ACL or tagging requests, got %d", n)
+	}
+}
+
+func TestReadKs3BucketObjectsTruncatedWithoutNextMarker(t *testing.T) {
+	bucket := &fakeKs3ObjectsReader{keys: []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}, pageSize: 2, calls: map[string]int{}}
+	d := schema.TestResourceDataRaw(t, dataSourceKsyunKs3BucketObjects().Schema, map[string]interface{}{
+		"bucket_name":      "my-bucket",
+		"include_metadata": false,
+		"include_acl":      false,
+		"include_tags":     false,
+	})
+	if err := readKs3BucketObjects(d, bucket, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	objects := d.Get("objects").([]interface{})
+	if len(objects) != len(bucket.keys) {
+		t.Fatalf("expected all %d objects to be listed, got %d", len(bucket.keys), len(objects))
+	}
+	for i, key := range bucket.keys {
+		if objects[i].(map[string]interface{})["key"] != key {
+			t.Errorf("expected the object %d to be %s, got %v", i, key, objects[i])
+		}
+	}
+	if n := bucket.count("ListObjects"); n != 3 {
+		t.Errorf("expected 3 pages to be listed, got %d requests", n)
+	}
+}
+
+func TestReadKs3BucketObjectsDetails(t *testing.T) {
+	bucket := &fakeKs3ObjectsReader{keys: []string{"a.txt"}, calls: map[string]int{}}
+	d := schema.TestResourceDataRaw(t, dataSourceKsyunKs3BucketObjects().Schema, map[string]interface{}{
+		"bucket_name":      "my-bucket",
+		"include_metadata": false,
+	})
+	if err := readKs3BucketObjects(d, bucket, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	object := d.Get("objects.0").(map[string]interface{})
+	if object["acl"] != "public-read" {
+		t.Errorf("expected the grants to be reported as public-read, got %q", object["acl"])
+	}
+	if object["tags"].(map[string]interface{})["env"] != "test" {
+		t.Errorf("expected the tags of the object, got %v", object["tags"])
+	}
+	if object["content_type"] != "" || bucket.count("GetObjectDetailedMeta") != 0 {
+		t.Errorf("expected the metadata to be skipped: %v", object)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
 	"log"
 	"regexp"
+	"sync"
 	"time"
 )
 
@@ -258,6 +259,11 @@ func dataSourceKsyunKs3BucketsRead(d *schema.ResourceData, meta interface{}) err
 		}
 	}
 
+	client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		ks3BucketCache.put(ks3Client, allBuckets)
+		return nil, nil
+	})
+
 	var filteredBucketsTemp []ks3.BucketProperties
 	nameRegex, ok := d.GetOk("name_regex")
 	if ok && nameRegex.(string) != "" {
@@ -487,26 +493,101 @@ func bucketsDescriptionAttributes(d *schema.ResourceData, buckets []ks3.BucketPr
 	return nil
 }
 
+// GetBucketInfo describes a single bucket. The existence of the bucket is checked with a HEAD request,
+// so a missing bucket results in a not found ks3.ServiceError. The storage class and creation date are
+// only returned by ListBuckets and are taken from a listing cached for the lifetime of the provider.
 func GetBucketInfo(client *ks3.Client, bucket string) (ks3.GetBucketInfoResult, error) {
+	if _, err := client.HeadBucket(bucket); err != nil {
+		return ks3.GetBucketInfoResult{}, err
+	}
 	acl, err := client.GetBucketACL(bucket)
 	if err != nil {
 		return ks3.GetBucketInfoResult{}, err
 	}
-	resp, err := client.ListBuckets()
+	properties, err := ks3BucketCache.get(client, bucket)
 	if err != nil {
 		return ks3.GetBucketInfoResult{}, err
 	}
-	for _, bucketInfo := range resp.Buckets {
-		if bucketInfo.Name == bucket {
-			return ks3.GetBucketInfoResult{
-				BucketInfo: ks3.BucketInfo{
-					XMLName:      bucketInfo.XMLName,
-					Name:         bucket,
-					Region:       bucketInfo.Region,
-					ACL:          string(acl.GetCannedACL()),
-					StorageClass: bucketInfo.Type,
-				}}, nil
+	location, err := client.GetBucketLocation(bucket)
+	if err != nil || location == "" {
+		log.Printf("[WARN] Unable to get the location of the bucket %s, using the bucket listing instead: %v", bucket, err)
+		location = properties.Region
+	}
+	return ks3.GetBucketInfoResult{
+		BucketInfo: ks3.BucketInfo{
+			XMLName:      properties.XMLName,
+			Name:         bucket,
+			Location:     location,
+			Region:       location,
+			CreationDate: properties.CreationDate,
+			ACL:          ks3CannedACL(acl),
+			Owner:        acl.Owner,
+			StorageClass: properties.Type,
+		}}, nil
+}
+
+// ks3BucketListCache keeps the result of ListBuckets per KS3 client, so that describing many buckets
+// in one run needs a single listing instead of one per bucket.
+type ks3BucketListCache struct {
+	mutex   sync.Mutex
+	buckets map[*ks3.Client]map[string]ks3.BucketProperties
+	list    func(client *ks3.Client) ([]ks3.BucketProperties, error)
+}
+
+var ks3BucketCache = &ks3BucketListCache{
+	buckets: map[*ks3.Client]map[string]ks3.BucketProperties{},
+	list:    listAllKs3Buckets,
+}
+
+// get returns the listed properties of the bucket. The listing is reloaded once when the bucket is
+// missing from it, as the bucket may have been created after the listing was cached. A bucket that is
+// still missing after the reload gets empty properties.
+func (c *ks3BucketListCache) get(client *ks3.Client, bucket string) (ks3.BucketProperties, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for reloaded := false; ; reloaded = true {
+		if properties, ok := c.buckets[client][bucket]; ok || reloaded {
+			return properties, nil
+		}
+		allBuckets, err := c.list(client)
+		if err != nil {
+			return ks3.BucketProperties{}, err
 		}
+		c.store(client, allBuckets)
+	}
+}
+
+func listAllKs3Buckets(client *ks3.Client) ([]ks3.BucketProperties, error) {
+	var allBuckets []ks3.BucketProperties
+	nextMarker := ""
+	for {
+		var options []ks3.Option
+		if nextMarker != "" {
+			options = append(options, ks3.Marker(nextMarker))
+		}
+		response, err := client.ListBuckets(options...)
+		if err != nil {
+			return nil, err
+		}
+		allBuckets = append(allBuckets, response.Buckets...)
+		nextMarker = response.NextMarker
+		if len(response.Buckets) == 0 || nextMarker == "" {
+			return allBuckets, nil
+		}
+	}
+}
+
+// put replaces the cached listing of the client.
+func (c *ks3BucketListCache) put(client *ks3.Client, buckets []ks3.BucketProperties) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.store(client, buckets)
+}
+
+func (c *ks3BucketListCache) store(client *ks3.Client, buckets []ks3.BucketProperties) {
+	listing := make(map[string]ks3.BucketProperties, len(buckets))
+	for _, bucket := range buckets {
+		listing[bucket.Name] = bucket
 	}
-	return ks3.GetBucketInfoResult{}, nil
+	c.buckets[client] = listing
 }
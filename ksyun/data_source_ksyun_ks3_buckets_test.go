@@ -0,0 +1,35 @@
+package ksyun
+
+import (
+	"testing"
+
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+func TestKs3BucketListCacheGet(t *testing.T) {
+	listings := 0
+	listed := []ks3.BucketProperties{{Name: "cached"}}
+	cache := &ks3BucketListCache{
+		buckets: map[*ks3.Client]map[string]ks3.BucketProperties{},
+		list: func(client *ks3.Client) ([]ks3.BucketProperties, error) {
+			listings++
+			return listed, nil
+		},
+	}
+	client := &ks3.Client{}
+	cache.put(client, []ks3.BucketProperties{{Name: "cached"}})
+
+	if properties, err := cache.get(client, "cached"); err != nil || properties.Name != "cached" || listings != 0 {
+		t.Errorf("expected the cached bucket without a listing, got %v, %v after %d listings", properties, err, listings)
+	}
+
+	listed = append(listed, ks3.BucketProperties{Name: "created"})
+	if properties, err := cache.get(client, "created"); err != nil || properties.Name != "created" || listings != 1 {
+		t.Errorf("expected the created bucket after one listing, got %v, %v after %d listings", properties, err, listings)
+	}
+
+	listings = 0
+	if properties, err := cache.get(client, "missing"); err != nil || properties.Name != "" || listings != 1 {
+		t.Errorf("expected empty properties after a single listing, got %v, %v after %d listings", properties, err, listings)
+	}
+}
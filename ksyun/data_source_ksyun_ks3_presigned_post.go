@@ -0,0 +1,166 @@
+package ksyun
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"time"
+)
+
+// The largest object a POST upload can create, which bounds the content length range when only its
+// minimum is set.
+const ks3PostObjectMaxSize = 5 * 1024 * 1024 * 1024
+
+// dataSourceKsyunKs3PresignedPost builds and signs a POST policy for browser uploads. Like the presigned
+// URL data source, it works offline with the provider credentials.
+func dataSourceKsyunKs3PresignedPost() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKsyunKs3PresignedPostRead,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"key_prefix"},
+			},
+			"key_prefix": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"key"},
+			},
+			"content_length_min": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"content_length_max": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"content_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"acl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+			},
+			"success_action_redirect": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"success_action_status": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"200", "201", "204"}, false),
+			},
+			"expires_in": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntBetween(1, 7*24*3600),
+			},
+
+			// Computed values
+			"url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fields": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"policy": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expiration": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceKsyunKs3PresignedPostRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+
+	fields := map[string]string{"KSSAccessKeyId": client.AccessKey}
+	conditions := []interface{}{map[string]string{"bucket": bucketName}}
+	if v, ok := d.GetOk("key"); ok {
+		fields["key"] = v.(string)
+		conditions = append(conditions, map[string]string{"key": v.(string)})
+	} else {
+		prefix := d.Get("key_prefix").(string)
+		fields["key"] = prefix + "${filename}"
+		conditions = append(conditions, []string{"starts-with", "$key", prefix})
+	}
+	minLength, hasMin := d.GetOk("content_length_min")
+	maxLength, hasMax := d.GetOk("content_length_max")
+	if hasMin || hasMax {
+		if !hasMax {
+			maxLength = ks3PostObjectMaxSize
+		}
+		conditions = append(conditions, []interface{}{"content-length-range", minLength.(int), maxLength.(int)})
+	}
+	for _, field := range []struct{ attribute, name string }{
+		{"content_type", "Content-Type"},
+		{"acl", "acl"},
+		{"success_action_redirect", "success_action_redirect"},
+		{"success_action_status", "success_action_status"},
+	} {
+		if v, ok := d.GetOk(field.attribute); ok {
+			fields[field.name] = v.(string)
+			conditions = append(conditions, map[string]string{field.name: v.(string)})
+		}
+	}
+
+	expiration := time.Now().Add(time.Duration(d.Get("expires_in").(int)) * time.Second).UTC()
+	policy, err := buildKs3PostPolicy(expiration, conditions)
+	if err != nil {
+		return WrapError(err)
+	}
+	fields["policy"] = policy
+	fields["signature"] = signKs3PostPolicy(policy, client.SecretKey)
+
+	d.SetId(dataResourceIdHash([]string{bucketName, fields["key"], policy}))
+	d.Set("url", ks3BucketUrl(client.Endpoint, bucketName))
+	d.Set("policy", policy)
+	d.Set("expiration", expiration.Format(time.RFC3339))
+	if err := d.Set("fields", fields); err != nil {
+		return WrapError(err)
+	}
+	return nil
+}
+
+// buildKs3PostPolicy returns the base64 encoded JSON policy document of a POST upload.
+func buildKs3PostPolicy(expiration time.Time, conditions []interface{}) (string, error) {
+	document, err := json.Marshal(map[string]interface{}{
+		"expiration": expiration.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(document), nil
+}
+
+// signKs3PostPolicy signs the encoded policy with HMAC-SHA1, as KS3 expects for POST uploads.
+func signKs3PostPolicy(policy, secretKey string) string {
+	h := hmac.New(sha1.New, []byte(secretKey))
+	h.Write([]byte(policy))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
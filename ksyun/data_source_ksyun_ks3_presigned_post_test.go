@@ -0,0 +1,99 @@
+package ksyun
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+)
+
+func TestBuildKs3PostPolicy(t *testing.T) {
+	expiration := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	policy, err := buildKs3PostPolicy(expiration, []interface{}{
+		map[string]string{"bucket": "my-bucket"},
+		[]string{"starts-with", "$key", "uploads/"},
+		[]interface{}{"content-length-range", 0, 1048576},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	document, err := base64.StdEncoding.DecodeString(policy)
+	if err != nil {
+		t.Fatalf("the policy is not base64 encoded: %s", err)
+	}
+	expected := `{"conditions":[{"bucket":"my-bucket"},["starts-with","$key","uploads/"],["content-length-range",0,1048576]],"expiration":"2030-01-02T03:04:05.000Z"}`
+	if string(document) != expected {
+		t.Errorf("got policy %s, expected %s", document, expected)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(document, &decoded); err != nil {
+		t.Errorf("the policy is not valid JSON: %s", err)
+	}
+}
+
+func TestSignKs3PostPolicy(t *testing.T) {
+	// HMAC-SHA1 of "policy" with the key "secret".
+	if signature := signKs3PostPolicy("policy", "secret"); signature != "DQYKsMXInaBeQ2OuQ0nMglptC1c=" {
+		t.Errorf("unexpected signature %s", signature)
+	}
+}
+
+func TestKs3BucketUrl(t *testing.T) {
+	cases := map[string]string{
+		"ks3-cn-beijing.ksyuncs.com":          "http://my-bucket.ks3-cn-beijing.ksyuncs.com/",
+		"https://ks3-cn-beijing.ksyuncs.com/": "https://my-bucket.ks3-cn-beijing.ksyuncs.com/",
+		"http://10.0.0.1:8080":                "http://10.0.0.1:8080/my-bucket/",
+	}
+	for endpoint, expected := range cases {
+		if got := ks3BucketUrl(endpoint, "my-bucket"); got != expected {
+			t.Errorf("ks3BucketUrl(%q) = %q, expected %q", endpoint, got, expected)
+		}
+	}
+}
+
+func TestDataSourceKsyunKs3PresignedPostContentLengthRange(t *testing.T) {
+	client := &connectivity.KsyunClient{
+		AccessKey: "AKTESTACCESSKEY",
+		SecretKey: "test-secret-key",
+		Endpoint:  "ks3-cn-beijing.invalid",
+	}
+	cases := []struct {
+		raw      map[string]interface{}
+		expected []interface{}
+	}{
+		{map[string]interface{}{"content_length_min": 1024}, []interface{}{"content-length-range", 1024.0, float64(ks3PostObjectMaxSize)}},
+		{map[string]interface{}{"content_length_max": 2048}, []interface{}{"content-length-range", 0.0, 2048.0}},
+		{map[string]interface{}{}, nil},
+	}
+	for i, c := range cases {
+		c.raw["bucket"] = "my-bucket"
+		c.raw["key_prefix"] = "uploads/"
+		d := schema.TestResourceDataRaw(t, dataSourceKsyunKs3PresignedPost().Schema, c.raw)
+		if err := dataSourceKsyunKs3PresignedPostRead(d, client); err != nil {
+			t.Fatalf("case %d: unexpected error: %s", i, err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(d.Get("policy").(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var policy struct {
+			Conditions []interface{} `json:"conditions"`
+		}
+		if err := json.Unmarshal(decoded, &policy); err != nil {
+			t.Fatal(err)
+		}
+		var lengthRange []interface{}
+		for _, condition := range policy.Conditions {
+			if v, ok := condition.([]interface{}); ok && v[0] == "content-length-range" {
+				lengthRange = v
+			}
+		}
+		if !reflect.DeepEqual(lengthRange, c.expected) {
+			t.Errorf("case %d: expected the condition %v, got %v", i, c.expected, lengthRange)
+		}
+	}
+}
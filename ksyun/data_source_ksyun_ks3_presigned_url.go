@@ -0,0 +1,126 @@
+package ksyun
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"time"
+)
+
+// dataSourceKsyunKs3PresignedUrl signs a URL for an object with the provider credentials. The URL is
+// signed locally, so reading the data source does not call KS3 and does not check that the object exists.
+func dataSourceKsyunKs3PresignedUrl() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKsyunKs3PresignedUrlRead,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"key": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"method": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  string(ks3.HTTPGet),
+				ValidateFunc: validation.StringInSlice([]string{
+					string(ks3.HTTPGet), string(ks3.HTTPPut), string(ks3.HTTPHead), string(ks3.HTTPDelete),
+				}, false),
+			},
+			"expires_in": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3600,
+				ValidateFunc: validation.IntBetween(1, 7*24*3600),
+			},
+			"content_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_content_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_content_disposition": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_content_encoding": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_content_language": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_cache_control": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"response_expires": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			// Computed values
+			"url": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"expiration": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceKsyunKs3PresignedUrlRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+	method := ks3.HTTPMethod(d.Get("method").(string))
+	expiresIn := int64(d.Get("expires_in").(int))
+
+	var options []ks3.Option
+	if v, ok := d.GetOk("content_type"); ok {
+		options = append(options, ks3.ContentType(v.(string)))
+	}
+	if v, ok := d.GetOk("response_content_type"); ok {
+		options = append(options, ks3.ResponseContentType(v.(string)))
+	}
+	if v, ok := d.GetOk("response_content_disposition"); ok {
+		options = append(options, ks3.ResponseContentDisposition(v.(string)))
+	}
+	if v, ok := d.GetOk("response_content_encoding"); ok {
+		options = append(options, ks3.ResponseContentEncoding(v.(string)))
+	}
+	if v, ok := d.GetOk("response_content_language"); ok {
+		options = append(options, ks3.ResponseContentLanguage(v.(string)))
+	}
+	if v, ok := d.GetOk("response_cache_control"); ok {
+		options = append(options, ks3.ResponseCacheControl(v.(string)))
+	}
+	if v, ok := d.GetOk("response_expires"); ok {
+		options = append(options, ks3.ResponseExpires(v.(string)))
+	}
+
+	expiration := time.Now().Add(time.Duration(expiresIn) * time.Second)
+	raw, err := client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		return bucket.SignURL(key, method, expiresIn, options...)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, ks3BucketObjectId(bucketName, key), "SignURL", KsyunKs3GoSdk)
+	}
+	url, _ := raw.(string)
+
+	d.SetId(dataResourceIdHash([]string{bucketName, key, string(method)}))
+	d.Set("url", url)
+	d.Set("expiration", expiration.UTC().Format(time.RFC3339))
+	return nil
+}
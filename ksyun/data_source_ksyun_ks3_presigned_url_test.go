@@ -0,0 +1,45 @@
+package ksyun
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+)
+
+// The URL is signed locally, so the data source can be read against an endpoint that does not exist.
+func TestDataSourceKsyunKs3PresignedUrlRead(t *testing.T) {
+	client := &connectivity.KsyunClient{
+		AccessKey: "AKTESTACCESSKEY",
+		SecretKey: "test-secret-key",
+		Endpoint:  "ks3-cn-beijing.invalid",
+	}
+	d := schema.TestResourceDataRaw(t, dataSourceKsyunKs3PresignedUrl().Schema, map[string]interface{}{
+		"bucket":                       "my-bucket",
+		"key":                          "reports/2024.csv",
+		"method":                       "PUT",
+		"expires_in":                   600,
+		"response_content_disposition": "attachment",
+	})
+	if err := dataSourceKsyunKs3PresignedUrlRead(d, client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	signed, err := url.Parse(d.Get("url").(string))
+	if err != nil {
+		t.Fatalf("invalid URL %q: %s", d.Get("url").(string), err)
+	}
+	if signed.Host != "my-bucket.ks3-cn-beijing.invalid" || signed.Path != "/reports/2024.csv" {
+		t.Errorf("unexpected URL %s", signed)
+	}
+	query := signed.Query()
+	for _, param := range []string{"Signature", "Expires", "response-content-disposition"} {
+		if query.Get(param) == "" {
+			t.Errorf("the URL %s has no %s parameter", signed, param)
+		}
+	}
+	if d.Get("expiration").(string) == "" {
+		t.Error("expiration is not set")
+	}
+}
@@ -3,8 +3,20 @@ package ksyun
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
 )
 
+const (
+	Ks3ServiceOpened    = "Opened"
+	Ks3ServiceNotOpened = "NotOpened"
+)
+
+// The error codes of a 403 that are caused by the credentials rather than by the account.
+var ks3InvalidCredentialsErrors = []string{"InvalidAccessKeyId", "SignatureDoesNotMatch", "RequestTimeTooSkewed"}
+
+// dataSourceKsyunKs3Service reports whether KS3 is opened for the account, along with the owner and the
+// number of buckets. The bucket limit of the account is not reported: KS3 has no API to read the quota.
 func dataSourceKsyunKs3Service() *schema.Resource {
 	return &schema.Resource{
 		Read: dataSourceKsyunKs3ServiceRead,
@@ -20,9 +32,96 @@ func dataSourceKsyunKs3Service() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"owner_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"owner_display_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bucket_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
 		},
 	}
 }
+
+// dataSourceKsyunKs3ServiceRead checks that KS3 is opened for the account by listing its buckets. KS3
+// can only be opened in the console, so with enable = "On" an account without KS3 is reported as an
+// error instead of being opened.
 func dataSourceKsyunKs3ServiceRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	var requestInfo *ks3.Client
+	var owner ks3.Owner
+	bucketCount := 0
+	status := Ks3ServiceOpened
+	nextMarker := ""
+	for {
+		var options []ks3.Option
+		if nextMarker != "" {
+			options = append(options, ks3.Marker(nextMarker))
+		}
+		raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+			requestInfo = ks3Client
+			return ks3Client.ListBuckets(options...)
+		})
+		if err != nil {
+			if ks3ServiceNotOpenedError(err) {
+				status = Ks3ServiceNotOpened
+				break
+			}
+			return WrapErrorf(err, DataDefaultErrorMsg, "ksyun_ks3_service", "ListBuckets", KsyunKs3GoSdk)
+		}
+		if debugOn() {
+			addDebug("ListBuckets", raw, requestInfo, map[string]interface{}{"options": options})
+		}
+		response, _ := raw.(ks3.ListBucketsResult)
+		owner = response.Owner
+		bucketCount += len(response.Buckets)
+
+		nextMarker = response.NextMarker
+		if !response.IsTruncated || nextMarker == "" {
+			break
+		}
+	}
+
+	if status == Ks3ServiceNotOpened && d.Get("enable").(string) == "On" {
+		return WrapError(Error("KS3 is not opened for this account and cannot be opened through the API, " +
+			"open it in the Kingsoft Cloud console first"))
+	}
+
+	if owner.ID != "" {
+		d.SetId(owner.ID)
+	} else {
+		d.SetId(dataResourceIdHash([]string{status}))
+	}
+	d.Set("status", status)
+	d.Set("owner_id", owner.ID)
+	d.Set("owner_display_name", owner.DisplayName)
+	d.Set("bucket_count", bucketCount)
 	return nil
 }
+
+// ks3ServiceNotOpenedError reports whether KS3 refused to list the buckets of valid credentials, which
+// is how it answers accounts that have not opened the service. A 403 caused by the credentials
+// themselves is a real error.
+func ks3ServiceNotOpenedError(err error) bool {
+	if e, ok := err.(*ComplexError); ok {
+		return ks3ServiceNotOpenedError(e.Cause)
+	}
+	var e ks3.ServiceError
+	switch v := err.(type) {
+	case ks3.ServiceError:
+		e = v
+	case *ks3.ServiceError:
+		if v == nil {
+			return false
+		}
+		e = *v
+	default:
+		return false
+	}
+	return e.StatusCode == 403 && !IsExpectedErrors(e, ks3InvalidCredentialsErrors)
+}
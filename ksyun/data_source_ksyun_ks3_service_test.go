@@ -0,0 +1,30 @@
+package ksyun
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+func TestKs3ServiceNotOpenedError(t *testing.T) {
+	accessDenied := ks3.ServiceError{StatusCode: 403, Code: "AccessDenied"}
+	cases := []struct {
+		err       error
+		notOpened bool
+	}{
+		{accessDenied, true},
+		{&accessDenied, true},
+		{WrapError(accessDenied), true},
+		{ks3.ServiceError{StatusCode: 403, Code: "RequestTimeTooSkewed"}, false},
+		{ks3.ServiceError{StatusCode: 403, Code: "InvalidAccessKeyId"}, false},
+		{ks3.ServiceError{StatusCode: 403, Code: "SignatureDoesNotMatch"}, false},
+		{ks3.ServiceError{StatusCode: 500, Code: "InternalError"}, false},
+		{errors.New("connection refused"), false},
+	}
+	for i, c := range cases {
+		if got := ks3ServiceNotOpenedError(c.err); got != c.notOpened {
+			t.Errorf("case %d: ks3ServiceNotOpenedError(%v) = %t, expected %t", i, c.err, got, c.notOpened)
+		}
+	}
+}
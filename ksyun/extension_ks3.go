@@ -1,7 +1,19 @@
 package ksyun
 
 import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
 )
 
@@ -13,13 +25,235 @@ const (
 )
 
 func ks3NotFoundError(err error) bool {
-	if e, ok := err.(ks3.ServiceError); ok &&
-		(e.StatusCode == 404 || strings.HasPrefix(e.Code, "NoSuch") || strings.HasPrefix(e.Message, "No Row found")) {
-		return true
+	switch e := err.(type) {
+	case *ComplexError:
+		return ks3NotFoundError(e.Cause)
+	case *ks3.ServiceError:
+		return e != nil && ks3NotFoundError(*e)
+	case ks3.ServiceError:
+		return e.StatusCode == 404 || strings.HasPrefix(e.Code, "NoSuch") || strings.HasPrefix(e.Message, "No Row found")
 	}
 	return false
 }
 
+// ks3ObjectMetadata returns the user metadata of an object from its x-kss-meta-* headers. HTTP header
+// names are case-insensitive, so the metadata keys are returned in lower case.
+func ks3ObjectMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for k, v := range header {
+		if len(v) == 0 || !strings.HasPrefix(http.CanonicalHeaderKey(k), ks3.HTTPHeaderKs3MetaPrefix) {
+			continue
+		}
+		metadata[strings.ToLower(k[len(ks3.HTTPHeaderKs3MetaPrefix):])] = v[0]
+	}
+	return metadata
+}
+
+func ks3TagsToMap(tags []ks3.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[tag.Key] = tag.Value
+	}
+	return result
+}
+
+// ks3BucketUrl returns the URL of the bucket on the endpoint, following the rules the KS3 SDK uses for
+// requests: plain HTTP unless the endpoint says otherwise, and path style access for IP endpoints.
+func ks3BucketUrl(endpoint, bucketName string) string {
+	scheme := "http"
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		scheme, endpoint = endpoint[:i], endpoint[i+len("://"):]
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+	}
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return scheme + "://" + endpoint + "/" + bucketName + "/"
+	}
+	return scheme + "://" + bucketName + "." + endpoint + "/"
+}
+
+// Headers that carry the customer provided key of the source object of a copy.
+const (
+	ks3HTTPHeaderCopySourceSSECAlgorithm = "X-Kss-Copy-Source-Server-Side-Encryption-Customer-Algorithm"
+	ks3HTTPHeaderCopySourceSSECKey       = "X-Kss-Copy-Source-Server-Side-Encryption-Customer-Key"
+	ks3HTTPHeaderCopySourceSSECKeyMd5    = "X-Kss-Copy-Source-Server-Side-Encryption-Customer-Key-MD5"
+)
+
+// ks3SSECKeyMd5 returns the base64 encoded MD5 of a base64 encoded customer provided key.
+func ks3SSECKeyMd5(key string) string {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return ""
+	}
+	sum := md5.Sum(decoded)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ks3SSECOptions returns the options that encrypt or decrypt an object with a customer provided key.
+func ks3SSECOptions(key string) []ks3.Option {
+	return []ks3.Option{
+		ks3.SSECAlgorithm(ServerSideEncryptionAes256),
+		ks3.SSECKey(key),
+		ks3.SSECKeyMd5(ks3SSECKeyMd5(key)),
+	}
+}
+
+// ks3CopySourceSSECOptions returns the options that decrypt the source object of a copy with a
+// customer provided key.
+func ks3CopySourceSSECOptions(key string) []ks3.Option {
+	return []ks3.Option{
+		ks3.SetHeader(ks3HTTPHeaderCopySourceSSECAlgorithm, ServerSideEncryptionAes256),
+		ks3.SetHeader(ks3HTTPHeaderCopySourceSSECKey, key),
+		ks3.SetHeader(ks3HTTPHeaderCopySourceSSECKeyMd5, ks3SSECKeyMd5(key)),
+	}
+}
+
+// ks3ACLCustom is read back as the acl of buckets and objects whose grants match no canned ACL.
+const ks3ACLCustom = "custom"
+
+func ks3GrantSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"id": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"uri": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"permission": {
+					Type:     schema.TypeString,
+					Required: true,
+					ValidateFunc: validation.StringInSlice([]string{
+						string(ks3.PermissionRead), string(ks3.PermissionWrite), string(ks3.PermissionFullControl),
+					}, false),
+				},
+			},
+		},
+	}
+}
+
+// ks3ACLDiffSuppressFunc ignores the canned acl while grant blocks define the ACL.
+func ks3ACLDiffSuppressFunc(k, old, new string, d *schema.ResourceData) bool {
+	return d.Get("grant").(*schema.Set).Len() > 0
+}
+
+type ks3GranteeXML struct {
+	XMLNSXsi string `xml:"xmlns:xsi,attr"`
+	Type     string `xml:"xsi:type,attr"`
+	ID       string `xml:"ID,omitempty"`
+	URI      string `xml:"URI,omitempty"`
+}
+
+type ks3GrantXML struct {
+	Grantee    ks3GranteeXML `xml:"Grantee"`
+	Permission string        `xml:"Permission"`
+}
+
+type ks3AccessControlPolicyXML struct {
+	XMLName xml.Name      `xml:"AccessControlPolicy"`
+	OwnerID string        `xml:"Owner>ID"`
+	Grants  []ks3GrantXML `xml:"AccessControlList>Grant"`
+}
+
+// expandKs3Grants builds the access control policy of the grant blocks. The owner always keeps full
+// control, as it does with every canned ACL.
+func expandKs3Grants(ownerID string, grants []interface{}) (*ks3AccessControlPolicyXML, error) {
+	policy := &ks3AccessControlPolicyXML{OwnerID: ownerID}
+	policy.Grants = append(policy.Grants, newKs3GrantXML(ownerID, "", string(ks3.PermissionFullControl)))
+	for _, v := range grants {
+		grant := v.(map[string]interface{})
+		id, uri := grant["id"].(string), grant["uri"].(string)
+		if (id == "") == (uri == "") {
+			return nil, fmt.Errorf("each grant must set exactly one of id and uri")
+		}
+		if id == ownerID && grant["permission"].(string) == string(ks3.PermissionFullControl) {
+			continue
+		}
+		policy.Grants = append(policy.Grants, newKs3GrantXML(id, uri, grant["permission"].(string)))
+	}
+	return policy, nil
+}
+
+func newKs3GrantXML(id, uri, permission string) ks3GrantXML {
+	grantee := ks3GranteeXML{XMLNSXsi: "http://www.w3.org/2001/XMLSchema-instance", ID: id, URI: uri}
+	if uri != "" {
+		grantee.Type = "Group"
+	} else {
+		grantee.Type = "CanonicalUser"
+	}
+	return ks3GrantXML{Grantee: grantee, Permission: permission}
+}
+
+// flattenKs3Grants returns the grants of the policy, leaving out the full control of the owner.
+func flattenKs3Grants(policy ks3.AccessControlPolicy) []map[string]interface{} {
+	grants := make([]map[string]interface{}, 0, len(policy.ACL))
+	for _, grant := range policy.ACL {
+		if grant.Grantee.ID == policy.Owner.ID && grant.Permission == ks3.PermissionFullControl {
+			continue
+		}
+		grants = append(grants, map[string]interface{}{
+			"id":         grant.Grantee.ID,
+			"uri":        grant.Grantee.Uri,
+			"permission": string(grant.Permission),
+		})
+	}
+	return grants
+}
+
+// ks3ReadGrants returns the grants to keep in state. An ACL that matches a canned ACL is tracked by the
+// acl argument alone, unless grant blocks are already in use.
+func ks3ReadGrants(d *schema.ResourceData, policy ks3.AccessControlPolicy) []map[string]interface{} {
+	if ks3CannedACL(policy) != ks3ACLCustom && d.Get("grant").(*schema.Set).Len() == 0 {
+		return nil
+	}
+	return flattenKs3Grants(policy)
+}
+
+// ks3CannedACL returns the canned ACL that grants the same permissions as the policy, or "custom" if
+// there is none. Unlike GetCannedACL, grants to specific users or other groups are not ignored.
+func ks3CannedACL(policy ks3.AccessControlPolicy) string {
+	var permissions []string
+	for _, grant := range flattenKs3Grants(policy) {
+		if grant["uri"].(string) != ks3.ALL_USERS {
+			return ks3ACLCustom
+		}
+		permissions = append(permissions, grant["permission"].(string))
+	}
+	sort.Strings(permissions)
+	switch strings.Join(permissions, ",") {
+	case "":
+		return string(ks3.ACLPrivate)
+	case string(ks3.PermissionRead):
+		return string(ks3.ACLPublicRead)
+	case string(ks3.PermissionRead) + "," + string(ks3.PermissionWrite):
+		return string(ks3.ACLPublicReadWrite)
+	}
+	return ks3ACLCustom
+}
+
+// putKs3AccessControlPolicy replaces the ACL of the object, or of the bucket when the key is empty.
+func putKs3AccessControlPolicy(bucket *ks3.Bucket, objectKey string, policy *ks3AccessControlPolicyXML) error {
+	body, err := xml.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	options := []ks3.Option{ks3.ContentType("application/xml")}
+	resp, err := bucket.Do("PUT", objectKey, map[string]interface{}{"acl": nil}, options, bytes.NewReader(body), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return ks3.CheckRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
 type ListenerErr struct {
 	ErrType string
 	Err     error
@@ -29,3 +263,32 @@ func (e *ListenerErr) Error() string {
 	return e.ErrType + " " + e.Err.Error()
 
 }
+
+// detectKs3ContentType returns the content type for the extension of the first name that has a known
+// one, falling back to sniffing the head of the content.
+func detectKs3ContentType(head []byte, names ...string) string {
+	for _, name := range names {
+		if contentType := ks3.TypeByExtension(name); contentType != "" {
+			return contentType
+		}
+	}
+	if len(head) > 0 {
+		return http.DetectContentType(head)
+	}
+	return "application/octet-stream"
+}
+
+// readKs3FileHead returns the first bytes of the file that are used to sniff its content type.
+func readKs3FileHead(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
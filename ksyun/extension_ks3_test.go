@@ -0,0 +1,111 @@
+package ksyun
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+func TestKs3NotFoundError(t *testing.T) {
+	notFound := ks3.ServiceError{StatusCode: 404}
+	cases := []struct {
+		err      error
+		notFound bool
+	}{
+		{notFound, true},
+		{&notFound, true},
+		{ks3.ServiceError{StatusCode: 400, Code: "NoSuchKey"}, true},
+		{WrapError(notFound), true},
+		{ks3.ServiceError{StatusCode: 403, Code: "AccessDenied"}, false},
+		{errors.New("404 Not Found"), false},
+		{nil, false},
+	}
+	for i, c := range cases {
+		if got := ks3NotFoundError(c.err); got != c.notFound {
+			t.Errorf("case %d: ks3NotFoundError(%v) = %t, expected %t", i, c.err, got, c.notFound)
+		}
+	}
+}
+
+func TestKs3SSECKeyMd5(t *testing.T) {
+	if md5 := ks3SSECKeyMd5("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="); md5 != "cLyPS3KoaSFGi/joRB3OUQ==" {
+		t.Errorf("unexpected key MD5 %s", md5)
+	}
+}
+
+func TestKs3CannedACL(t *testing.T) {
+	owner := ks3.Grant{Grantee: ks3.Grantee{ID: "owner"}, Permission: ks3.PermissionFullControl}
+	allUsers := func(permission ks3.Permission) ks3.Grant {
+		return ks3.Grant{Grantee: ks3.Grantee{Uri: ks3.ALL_USERS}, Permission: permission}
+	}
+	cases := []struct {
+		grants []ks3.Grant
+		acl    string
+	}{
+		{[]ks3.Grant{owner}, "private"},
+		{[]ks3.Grant{owner, allUsers(ks3.PermissionRead)}, "public-read"},
+		{[]ks3.Grant{owner, allUsers(ks3.PermissionWrite), allUsers(ks3.PermissionRead)}, "public-read-write"},
+		{[]ks3.Grant{owner, allUsers(ks3.PermissionWrite)}, ks3ACLCustom},
+		{[]ks3.Grant{owner, {Grantee: ks3.Grantee{ID: "other"}, Permission: ks3.PermissionRead}}, ks3ACLCustom},
+	}
+	for i, c := range cases {
+		policy := ks3.AccessControlPolicy{ACL: c.grants}
+		policy.Owner.ID = "owner"
+		if acl := ks3CannedACL(policy); acl != c.acl {
+			t.Errorf("case %d: expected %s, got %s", i, c.acl, acl)
+		}
+	}
+}
+
+func TestExpandKs3Grants(t *testing.T) {
+	policy, err := expandKs3Grants("owner", []interface{}{
+		map[string]interface{}{"id": "other", "uri": "", "permission": "READ"},
+		map[string]interface{}{"id": "", "uri": ks3.ALL_USERS, "permission": "WRITE"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := xml.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<Owner><ID>owner</ID></Owner>`,
+		`xsi:type="CanonicalUser"><ID>other</ID></Grantee><Permission>READ</Permission>`,
+		`xsi:type="Group"><URI>` + ks3.ALL_USERS + `</URI></Grantee><Permission>WRITE</Permission>`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in %s", expected, body)
+		}
+	}
+
+	_, err = expandKs3Grants("owner", []interface{}{
+		map[string]interface{}{"id": "other", "uri": ks3.ALL_USERS, "permission": "READ"},
+	})
+	if err == nil {
+		t.Error("expected an error for a grant with both id and uri")
+	}
+}
+
+func TestDetectKs3ContentType(t *testing.T) {
+	cases := []struct {
+		head         string
+		names        []string
+		expectedType string
+	}{
+		{"", []string{"index.html"}, "text/html; charset=utf-8"},
+		{"", []string{"index", "site/index.html"}, "text/html; charset=utf-8"},
+		{"{}", []string{"data.json", "data.txt"}, "application/json"},
+		{"<!DOCTYPE html><html></html>", []string{"index", ""}, "text/html; charset=utf-8"},
+		{"\x89PNG\r\n\x1a\n", []string{"logo"}, "image/png"},
+		{"", []string{"blob"}, "application/octet-stream"},
+	}
+	for i, c := range cases {
+		if contentType := detectKs3ContentType([]byte(c.head), c.names...); contentType != c.expectedType {
+			t.Errorf("case %d: expected %s, got %s", i, c.expectedType, contentType)
+		}
+	}
+}
@@ -67,12 +67,18 @@ func Provider() terraform.ResourceProvider {
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"ksyun_ks3_service":        dataSourceKsyunKs3Service(),
+			"ksyun_ks3_bucket_object":  dataSourceKsyunKs3BucketObject(),
 			"ksyun_ks3_bucket_objects": dataSourceKsyunKs3BucketObjects(),
 			"ksyun_ks3_buckets":        dataSourceKsyunKs3Buckets(),
+			"ksyun_ks3_presigned_url":  dataSourceKsyunKs3PresignedUrl(),
+			"ksyun_ks3_presigned_post": dataSourceKsyunKs3PresignedPost(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"ksyun_ks3_bucket":        resourceKsyunKs3Bucket(),
-			"ksyun_ks3_bucket_object": resourceKsyunKs3BucketObject(),
+			"ksyun_ks3_bucket":           resourceKsyunKs3Bucket(),
+			"ksyun_ks3_bucket_object":    resourceKsyunKs3BucketObject(),
+			"ksyun_ks3_object_copy":      resourceKsyunKs3ObjectCopy(),
+			"ksyun_ks3_bucket_directory": resourceKsyunKs3BucketDirectory(),
+			"ksyun_ks3_object_restore":   resourceKsyunKs3ObjectRestore(),
 		},
 
 		ConfigureFunc: providerConfigure,
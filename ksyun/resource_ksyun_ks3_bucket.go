@@ -24,22 +24,39 @@ func resourceKsyunKs3Bucket() *schema.Resource {
 			State: schema.ImportStatePassthrough,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"bucket": {
-				Type:         schema.TypeString,
-				Optional:     true,
-				ForceNew:     true,
-				ValidateFunc: validation.StringLenBetween(3, 63),
-				Default:      resource.PrefixedUniqueId("ks3-bucket-"),
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"bucket_prefix"},
+				ValidateFunc:  validateKs3BucketName,
+			},
+
+			"bucket_prefix": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"bucket"},
+				ValidateFunc:  validateKs3BucketPrefix,
 			},
 
 			"acl": {
-				Type:         schema.TypeString,
-				Default:      ks3.ACLPrivate,
-				Optional:     true,
-				ValidateFunc: validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+				Type:             schema.TypeString,
+				Default:          ks3.ACLPrivate,
+				Optional:         true,
+				ValidateFunc:     validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+				DiffSuppressFunc: ks3ACLDiffSuppressFunc,
 			},
 
+			"grant": ks3GrantSchema(),
+
 			"cors_rule": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -223,13 +240,52 @@ func resourceKsyunKs3Bucket() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+
+			"location": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"extranet_endpoint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"intranet_endpoint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"bucket_domain_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"force_destroy": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 		},
 	}
 }
 
 func resourceKsyunKs3BucketCreate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*connectivity.KsyunClient)
-	request := map[string]string{"bucketName": d.Get("bucket").(string)}
+	var bucketName string
+	if v, ok := d.GetOk("bucket"); ok {
+		bucketName = v.(string)
+	} else if v, ok := d.GetOk("bucket_prefix"); ok {
+		bucketName = resource.PrefixedUniqueId(v.(string))
+	} else {
+		bucketName = resource.PrefixedUniqueId("ks3-bucket-")
+	}
+	request := map[string]string{"bucketName": bucketName}
 	var requestInfo *ks3.Client
 	type Request struct {
 		BucketName         string
@@ -238,7 +294,7 @@ func resourceKsyunKs3BucketCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	req := Request{
-		d.Get("bucket").(string),
+		bucketName,
 		ks3.BucketTypeClass(ks3.BucketType(d.Get("storage_class").(string))),
 		ks3.ACL(ks3.ACLType(d.Get("acl").(string))),
 	}
@@ -251,6 +307,12 @@ func resourceKsyunKs3BucketCreate(d *schema.ResourceData, meta interface{}) erro
 	addDebug("CreateBucket", raw, requestInfo, req)
 	d.SetId(request["bucketName"])
 
+	// A new bucket is not visible to every request right away.
+	ks3Service := Ks3Service{client}
+	if err := ks3Service.WaitForKs3Bucket(d.Id(), Available, int(d.Timeout(schema.TimeoutCreate).Seconds())); err != nil {
+		return WrapError(err)
+	}
+
 	return resourceKsyunKs3BucketUpdate(d, meta)
 }
 
@@ -269,15 +331,34 @@ func resourceKsyunKs3BucketRead(d *schema.ResourceData, meta interface{}) error
 	d.Set("bucket", d.Id())
 	d.Set("acl", object.BucketInfo.ACL)
 	d.Set("creation_date", object.BucketInfo.CreationDate.Format("2006-01-02"))
-	d.Set("location", object.BucketInfo.Region)
+	d.Set("location", object.BucketInfo.Location)
 	d.Set("owner", object.BucketInfo.Owner.ID)
 	d.Set("storage_class", object.BucketInfo.StorageClass)
 
+	endpoint := client.Ks3EndpointByRegion(connectivity.Region(object.BucketInfo.Location))
+	d.Set("extranet_endpoint", endpoint.Extranet)
+	d.Set("intranet_endpoint", endpoint.Intranet)
+	d.Set("bucket_domain_name", fmt.Sprintf("%s.%s", d.Id(), endpoint.Extranet))
+
 	request := map[string]string{"bucketName": d.Id()}
 	var requestInfo *ks3.Client
 
-	// Read the CORS
+	// Read the ACL grants
 	raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		requestInfo = ks3Client
+		return ks3Client.GetBucketACL(request["bucketName"])
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetBucketACL", KsyunKs3GoSdk)
+	}
+	addDebug("GetBucketACL", raw, requestInfo, request)
+	acl, _ := raw.(ks3.GetBucketACLResult)
+	if err := d.Set("grant", ks3ReadGrants(d, acl)); err != nil {
+		return WrapError(err)
+	}
+
+	// Read the CORS
+	raw, err = client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
 		requestInfo = ks3Client
 		return ks3Client.GetBucketCORS(request["bucketName"])
 	})
@@ -445,12 +526,43 @@ func resourceKsyunKs3BucketRead(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
+func resourceKsyunKs3BucketGrantUpdate(client *connectivity.KsyunClient, d *schema.ResourceData) error {
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		requestInfo = ks3Client
+		return ks3Client.GetBucketACL(d.Id())
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetBucketACL", KsyunKs3GoSdk)
+	}
+	acl, _ := raw.(ks3.GetBucketACLResult)
+	policy, err := expandKs3Grants(acl.Owner.ID, d.Get("grant").(*schema.Set).List())
+	if err != nil {
+		return WrapError(err)
+	}
+
+	raw, err = client.WithKs3BucketByName(d.Id(), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return nil, putKs3AccessControlPolicy(bucket, "", policy)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "PutBucketACL", KsyunKs3GoSdk)
+	}
+	addDebug("PutBucketACL", raw, requestInfo, policy)
+	return nil
+}
+
 func resourceKsyunKs3BucketUpdate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*connectivity.KsyunClient)
 
 	d.Partial(true)
 
-	if d.HasChange("acl") && !d.IsNewResource() {
+	if d.HasChange("grant") && d.Get("grant").(*schema.Set).Len() > 0 {
+		if err := resourceKsyunKs3BucketGrantUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+		d.SetPartial("grant")
+	} else if (d.HasChange("acl") || d.HasChange("grant")) && !d.IsNewResource() {
 		request := map[string]string{"bucketName": d.Id(), "bucketACL": d.Get("acl").(string)}
 		var requestInfo *ks3.Client
 		raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
@@ -748,6 +860,7 @@ func resourceKsyunKs3BucketPolicyUpdate(client *connectivity.KsyunClient, d *sch
 
 func resourceKsyunKs3BucketDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*connectivity.KsyunClient)
+	ks3Service := Ks3Service{client}
 	var requestInfo *ks3.Client
 	raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
 		requestInfo = ks3Client
@@ -757,50 +870,43 @@ func resourceKsyunKs3BucketDelete(d *schema.ResourceData, meta interface{}) erro
 		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "IsBucketExist", KsyunKs3GoSdk)
 	}
 	addDebug("IsBucketExist", raw, requestInfo, map[string]string{"bucketName": d.Id()})
+	if exist, _ := raw.(bool); !exist {
+		return nil
+	}
 
-	err = resource.Retry(5*time.Minute, func() *resource.RetryError {
-		raw, err = client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+	err = resource.Retry(d.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
+		raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
 			return nil, ks3Client.DeleteBucket(d.Id())
 		})
 		if err != nil {
-			if IsExpectedErrors(err, []string{"BucketNotEmpty"}) {
-				raw, er := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
-					bucket, _ := ks3Client.Bucket(d.Get("bucket").(string))
-					marker := ""
-					retryTime := 3
-					for retryTime > 0 {
-						lsRes, err := bucket.ListObjects(ks3.Marker(marker))
-						if err != nil {
-							retryTime--
-							time.Sleep(time.Duration(1) * time.Second)
-							continue
-						}
-						for _, object := range lsRes.Objects {
-							bucket.DeleteObject(object.Key)
-						}
-						if lsRes.IsTruncated {
-							marker = lsRes.NextMarker
-						} else {
-							return true, nil
-						}
-					}
-					return false, nil
-				})
+			if !IsExpectedErrors(err, []string{"BucketNotEmpty"}) {
+				return resource.NonRetryableError(err)
+			}
+			if !d.Get("force_destroy").(bool) {
+				keys, er := ks3Service.ListKs3BucketObjectKeys(d.Id(), 10)
 				if er != nil {
 					return resource.NonRetryableError(er)
 				}
-				addDebug("DeleteObjects", raw, requestInfo, map[string]string{"bucketName": d.Id()})
-				return resource.RetryableError(err)
+				remaining := "object versions or incomplete multipart uploads"
+				if len(keys) > 0 {
+					remaining = "keys such as " + strings.Join(keys, ", ")
+				}
+				return resource.NonRetryableError(Error("The bucket %s is not empty, it still contains %s. "+
+					"Set force_destroy to true to delete all objects, versions and multipart uploads along with the bucket.",
+					d.Id(), remaining))
 			}
+			if er := ks3Service.EmptyKs3Bucket(d.Id()); er != nil {
+				return resource.NonRetryableError(er)
+			}
+			return resource.RetryableError(err)
 		}
-		return resource.NonRetryableError(err)
 		addDebug("DeleteBucket", raw, requestInfo, map[string]string{"bucketName": d.Id()})
 		return nil
 	})
 	if err != nil {
 		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "DeleteBucket", KsyunKs3GoSdk)
 	}
-	return nil
+	return WrapError(ks3Service.WaitForKs3Bucket(d.Id(), Deleted, int(d.Timeout(schema.TimeoutDelete).Seconds())))
 }
 
 func transitionsHash(v interface{}) int {
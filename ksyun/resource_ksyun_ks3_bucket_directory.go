@@ -0,0 +1,502 @@
+package ksyun
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/mitchellh/go-homedir"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// resourceKsyunKs3BucketDirectory keeps the objects under a prefix of a bucket in sync with a local
+// directory. The ETag of every synced object is tracked in the computed files map, keyed by the path
+// relative to the prefix, so all uploads and deletions of an apply show up as a single diff. The files
+// synced by the resource are tracked apart in uploaded_files, so that destroying it leaves the objects
+// other tools put under the prefix alone.
+func resourceKsyunKs3BucketDirectory() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKsyunKs3BucketDirectorySync,
+		Read:   resourceKsyunKs3BucketDirectoryRead,
+		Update: resourceKsyunKs3BucketDirectorySync,
+		Delete: resourceKsyunKs3BucketDirectoryDelete,
+
+		CustomizeDiff: resourceKsyunKs3BucketDirectoryCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"prefix": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"source": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"include": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"exclude": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"cache_control": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pattern": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+
+			"acl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      ks3.ACLPrivate,
+				ValidateFunc: validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+			},
+
+			"delete_removed": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"upload_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8,
+				ValidateFunc: validation.IntBetween(1, 100),
+			},
+
+			"files": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"uploaded_files": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// ks3BucketDirectoryUploadAttributes are the arguments that change how files are stored, so a change
+// to any of them uploads every file again.
+var ks3BucketDirectoryUploadAttributes = []string{"acl", "cache_control"}
+
+func resourceKsyunKs3BucketDirectorySync(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+	prefix := ks3DirectoryPrefix(d.Get("prefix").(string))
+	filter := newKs3DirectoryFilter(d)
+
+	local, err := ks3DirectoryLocalFiles(d.Get("source").(string), filter)
+	if err != nil {
+		return WrapError(err)
+	}
+	remote, err := ks3DirectoryRemoteFiles(client, bucketName, prefix, filter)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	reupload := !d.IsNewResource() && d.HasChanges(ks3BucketDirectoryUploadAttributes...)
+	var uploads []string
+	for rel, file := range local {
+		if etag, ok := remote[rel]; reupload || !ok || !strings.EqualFold(etag, file.etag) {
+			uploads = append(uploads, rel)
+		}
+	}
+	sort.Strings(uploads)
+
+	deletes := ks3DirectorySyncDeletes(prefix, local, remote, d.Get("uploaded_files").(*schema.Set), d.Get("delete_removed").(bool))
+	log.Printf("[INFO] Syncing %s to %s: %d file(s) to upload, %d object(s) to delete",
+		d.Get("source").(string), ks3BucketObjectId(bucketName, prefix), len(uploads), len(deletes))
+
+	raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		return ks3Client.Bucket(bucketName)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, bucketName, "Bucket", KsyunKs3GoSdk)
+	}
+	bucket, _ := raw.(*ks3.Bucket)
+
+	failures := uploadKs3DirectoryFiles(bucket, prefix, uploads, local, d)
+	for len(deletes) > 0 {
+		size := len(deletes)
+		if size > ks3DeleteObjectsBatchSize {
+			size = ks3DeleteObjectsBatchSize
+		}
+		failures = append(failures, deleteKs3ObjectBatch(bucket, deletes[:size])...)
+		deletes = deletes[size:]
+	}
+
+	d.SetId(ks3BucketObjectId(bucketName, prefix))
+	files := make(map[string]string, len(local))
+	uploaded := make([]string, 0, len(local))
+	for rel, file := range local {
+		files[rel] = file.etag
+		uploaded = append(uploaded, rel)
+	}
+	if err := d.Set("files", files); err != nil {
+		return WrapError(err)
+	}
+	if err := d.Set("uploaded_files", uploaded); err != nil {
+		return WrapError(err)
+	}
+	if len(failures) > 0 {
+		return WrapError(Error("Failed to sync %d file(s) to %s:\n%s", len(failures), d.Id(), strings.Join(failures, "\n")))
+	}
+	return resourceKsyunKs3BucketDirectoryRead(d, meta)
+}
+
+// ks3DirectorySyncDeletes returns the objects to delete when syncing: the files synced before that are
+// no longer in the directory and, with delete_removed, every other object under the prefix that is not
+// in the directory.
+func ks3DirectorySyncDeletes(prefix string, local map[string]ks3DirectoryFile, remote map[string]string, uploaded *schema.Set, deleteRemoved bool) []ks3.DeleteObject {
+	var deletes []ks3.DeleteObject
+	for rel := range remote {
+		if _, ok := local[rel]; ok {
+			continue
+		}
+		if deleteRemoved || uploaded.Contains(rel) {
+			deletes = append(deletes, ks3.DeleteObject{Key: prefix + rel})
+		}
+	}
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].Key < deletes[j].Key })
+	return deletes
+}
+
+// ks3DirectoryUploadedObjects returns the objects of the files synced by the resource, which are the only
+// ones deleted with it, even when delete_removed tracks other objects under the prefix.
+func ks3DirectoryUploadedObjects(prefix string, uploaded *schema.Set) []ks3.DeleteObject {
+	var objects []ks3.DeleteObject
+	for _, rel := range uploaded.List() {
+		objects = append(objects, ks3.DeleteObject{Key: prefix + rel.(string)})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects
+}
+
+// uploadKs3DirectoryFiles uploads the files with a bounded number of workers and returns a description
+// of every upload that failed.
+func uploadKs3DirectoryFiles(bucket *ks3.Bucket, prefix string, uploads []string, local map[string]ks3DirectoryFile, d *schema.ResourceData) (failures []string) {
+	acl := ks3.ACLType(d.Get("acl").(string))
+	cacheControl := d.Get("cache_control").([]interface{})
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for i := 0; i < d.Get("upload_concurrency").(int); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range jobs {
+				head, err := readKs3FileHead(local[rel].path)
+				if err != nil {
+					mutex.Lock()
+					failures = append(failures, fmt.Sprintf("%s: %s", rel, err))
+					mutex.Unlock()
+					continue
+				}
+				options := []ks3.Option{ks3.ObjectACL(acl), ks3.ContentType(detectKs3ContentType(head, rel))}
+				if v := ks3DirectoryCacheControl(cacheControl, rel); v != "" {
+					options = append(options, ks3.CacheControl(v))
+				}
+				err = uploadKs3ObjectFromFile(bucket, prefix+rel, local[rel].path, ks3DefaultPartSize, 1, options)
+				if err == nil {
+					continue
+				}
+				mutex.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %s", rel, err))
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, rel := range uploads {
+		jobs <- rel
+	}
+	close(jobs)
+	wg.Wait()
+	return failures
+}
+
+func resourceKsyunKs3BucketDirectoryRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+	remote, err := ks3DirectoryRemoteFiles(client, bucketName, ks3DirectoryPrefix(d.Get("prefix").(string)), newKs3DirectoryFilter(d))
+	if err != nil {
+		if ks3NotFoundError(err) {
+			log.Printf("[WARN] Ks3 bucket %s not found, removing %s from state", bucketName, d.Id())
+			d.SetId("")
+			return nil
+		}
+		return WrapError(err)
+	}
+	// Objects that were not uploaded by this resource are only tracked when they are to be deleted.
+	uploaded := d.Get("uploaded_files").(*schema.Set)
+	files := make(map[string]string)
+	for rel, etag := range remote {
+		if uploaded.Contains(rel) || d.Get("delete_removed").(bool) {
+			files[rel] = etag
+		}
+	}
+	return WrapError(d.Set("files", files))
+}
+
+func resourceKsyunKs3BucketDirectoryDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	bucketName := d.Get("bucket").(string)
+	prefix := ks3DirectoryPrefix(d.Get("prefix").(string))
+	raw, err := client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		return ks3Client.Bucket(bucketName)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "Bucket", KsyunKs3GoSdk)
+	}
+	bucket, _ := raw.(*ks3.Bucket)
+
+	deletes := ks3DirectoryUploadedObjects(prefix, d.Get("uploaded_files").(*schema.Set))
+	var failures []string
+	for len(deletes) > 0 {
+		size := len(deletes)
+		if size > ks3DeleteObjectsBatchSize {
+			size = ks3DeleteObjectsBatchSize
+		}
+		failures = append(failures, deleteKs3ObjectBatch(bucket, deletes[:size])...)
+		deletes = deletes[size:]
+	}
+	if len(failures) > 0 {
+		return WrapError(Error("Failed to delete %d object(s) of %s:\n%s", len(failures), d.Id(), strings.Join(failures, "\n")))
+	}
+	return nil
+}
+
+// resourceKsyunKs3BucketDirectoryCustomizeDiff plans the files map from the local directory, so that
+// added, changed and removed files all appear as changes of that one attribute.
+func resourceKsyunKs3BucketDirectoryCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	for _, key := range []string{"source", "include", "exclude"} {
+		if !d.NewValueKnown(key) {
+			return d.SetNewComputed("files")
+		}
+	}
+	if d.Id() != "" {
+		for _, key := range ks3BucketDirectoryUploadAttributes {
+			if d.HasChange(key) {
+				return d.SetNewComputed("files")
+			}
+		}
+	}
+	filter := &ks3DirectoryFilter{
+		include: expandStringList(d.Get("include").([]interface{})),
+		exclude: expandStringList(d.Get("exclude").([]interface{})),
+	}
+	local, err := ks3DirectoryLocalFiles(d.Get("source").(string), filter)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	files := make(map[string]interface{}, len(local))
+	for rel, file := range local {
+		files[rel] = file.etag
+	}
+	old := d.Get("files").(map[string]interface{})
+	changed := len(old) != len(files)
+	for rel, etag := range files {
+		if v, ok := old[rel]; !ok || !strings.EqualFold(v.(string), etag.(string)) {
+			changed = true
+			break
+		}
+	}
+	if changed {
+		return d.SetNew("files", files)
+	}
+	return nil
+}
+
+type ks3DirectoryFile struct {
+	path string
+	etag string
+}
+
+// ks3DirectoryLocalFiles walks the directory and returns the files selected by the filter, keyed by
+// their slash separated path relative to the directory.
+func ks3DirectoryLocalFiles(source string, filter *ks3DirectoryFilter) (map[string]ks3DirectoryFile, error) {
+	root, err := homedir.Expand(source)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]ks3DirectoryFile)
+	err = filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !filter.match(rel) {
+			return nil
+		}
+		etag, err := ks3FileEtag(filePath, ks3DefaultPartSize)
+		if err != nil {
+			return err
+		}
+		files[rel] = ks3DirectoryFile{path: filePath, etag: etag}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading the directory %s: %s", source, err)
+	}
+	return files, nil
+}
+
+// ks3DirectoryRemoteFiles lists the objects under the prefix that are selected by the filter and
+// returns their ETags, keyed by the key relative to the prefix.
+func ks3DirectoryRemoteFiles(client *connectivity.KsyunClient, bucketName, prefix string, filter *ks3DirectoryFilter) (map[string]string, error) {
+	files := make(map[string]string)
+	marker := ""
+	for {
+		raw, err := client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+			return bucket.ListObjects(ks3.Prefix(prefix), ks3.Marker(marker), ks3.MaxKeys(1000))
+		})
+		if err != nil {
+			return nil, WrapErrorf(err, DefaultErrorMsg, bucketName, "ListObjects", KsyunKs3GoSdk)
+		}
+		response, _ := raw.(ks3.ListObjectsResult)
+		for _, object := range response.Objects {
+			rel := strings.TrimPrefix(object.Key, prefix)
+			if rel == "" || strings.HasSuffix(rel, "/") || !filter.match(rel) {
+				continue
+			}
+			files[rel] = strings.Trim(object.ETag, `"`)
+		}
+		if !response.IsTruncated || len(response.Objects) == 0 {
+			break
+		}
+		marker = response.NextMarker
+		if marker == "" {
+			marker = response.Objects[len(response.Objects)-1].Key
+		}
+	}
+	return files, nil
+}
+
+// ks3DirectoryPrefix returns the prefix that file paths are appended to, which ends with a slash
+// unless it is empty.
+func ks3DirectoryPrefix(prefix string) string {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// ks3DirectoryCacheControl returns the value of the first cache_control block whose pattern matches
+// the file.
+func ks3DirectoryCacheControl(blocks []interface{}, rel string) string {
+	for _, v := range blocks {
+		block := v.(map[string]interface{})
+		if ks3GlobMatch(block["pattern"].(string), rel) {
+			return block["value"].(string)
+		}
+	}
+	return ""
+}
+
+type ks3DirectoryFilter struct {
+	include []string
+	exclude []string
+}
+
+func newKs3DirectoryFilter(d *schema.ResourceData) *ks3DirectoryFilter {
+	return &ks3DirectoryFilter{
+		include: expandStringList(d.Get("include").([]interface{})),
+		exclude: expandStringList(d.Get("exclude").([]interface{})),
+	}
+}
+
+// match reports whether the file is selected: it must match one of the include patterns, if there
+// are any, and none of the exclude patterns.
+func (f *ks3DirectoryFilter) match(rel string) bool {
+	for _, pattern := range f.exclude {
+		if ks3GlobMatch(pattern, rel) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if ks3GlobMatch(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ks3GlobMatch matches a slash separated path against a glob pattern. "*" and "?" do not match a
+// slash, while "**" matches any number of directories. A pattern without a slash is matched against
+// the file name only, so "*.html" selects HTML files in every directory.
+func ks3GlobMatch(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	matched, err := regexp.MatchString(expr.String(), name)
+	return err == nil && matched
+}
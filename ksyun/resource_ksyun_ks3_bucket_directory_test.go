@@ -0,0 +1,122 @@
+package ksyun
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+func TestKs3GlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		matched bool
+	}{
+		{"*.html", "index.html", true},
+		{"*.html", "docs/guide/index.html", true},
+		{"*.html", "index.htm", false},
+		{"assets/*", "assets/app.js", true},
+		{"assets/*", "assets/img/logo.png", false},
+		{"assets/**", "assets/img/logo.png", true},
+		{"**/*.map", "app.js.map", true},
+		{"**/*.map", "assets/js/app.js.map", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"a+b.txt", "a+b.txt", true},
+	}
+	for _, c := range cases {
+		if got := ks3GlobMatch(c.pattern, c.name); got != c.matched {
+			t.Errorf("ks3GlobMatch(%q, %q) = %t, expected %t", c.pattern, c.name, got, c.matched)
+		}
+	}
+}
+
+func TestKs3DirectoryLocalFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ks3-directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"index.html":         "hello",
+		"assets/app.js":      "",
+		"assets/app.js.map":  "",
+		"drafts/readme.html": "",
+	} {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ks3DirectoryLocalFiles(dir, &ks3DirectoryFilter{exclude: []string{"*.map", "drafts/**"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", files)
+	}
+	if files["index.html"].etag != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("unexpected ETag for index.html: %s", files["index.html"].etag)
+	}
+	if _, ok := files["assets/app.js"]; !ok {
+		t.Errorf("assets/app.js is missing from %v", files)
+	}
+}
+
+func TestKs3DirectoryPrefix(t *testing.T) {
+	for prefix, expected := range map[string]string{"": "", "site": "site/", "/site/": "site/"} {
+		if got := ks3DirectoryPrefix(prefix); got != expected {
+			t.Errorf("ks3DirectoryPrefix(%q) = %q, expected %q", prefix, got, expected)
+		}
+	}
+}
+
+func TestKs3DirectorySyncDeletes(t *testing.T) {
+	local := map[string]ks3DirectoryFile{"index.html": {etag: "1"}}
+	remote := map[string]string{"index.html": "1", "old.html": "2", "foreign.txt": "3"}
+	uploaded := schema.NewSet(schema.HashString, []interface{}{"index.html", "old.html"})
+
+	keys := func(objects []ks3.DeleteObject) []string {
+		var keys []string
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+		return keys
+	}
+	if got := keys(ks3DirectorySyncDeletes("site/", local, remote, uploaded, false)); !reflect.DeepEqual(got, []string{"site/old.html"}) {
+		t.Errorf("expected only the removed synced file to be deleted, got %v", got)
+	}
+	if got := keys(ks3DirectorySyncDeletes("site/", local, remote, uploaded, true)); !reflect.DeepEqual(got, []string{"site/foreign.txt", "site/old.html"}) {
+		t.Errorf("expected delete_removed to delete every object missing locally, got %v", got)
+	}
+}
+
+// With delete_removed, the files map also tracks the objects other tools put under the prefix, but
+// destroying the resource only deletes the synced files.
+func TestKs3DirectoryUploadedObjects(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKsyunKs3BucketDirectory().Schema, map[string]interface{}{
+		"bucket":         "my-bucket",
+		"prefix":         "site",
+		"source":         "/tmp/site",
+		"delete_removed": true,
+	})
+	if err := d.Set("files", map[string]interface{}{"index.html": "1", "foreign.txt": "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("uploaded_files", []interface{}{"index.html"}); err != nil {
+		t.Fatal(err)
+	}
+	got := ks3DirectoryUploadedObjects("site/", d.Get("uploaded_files").(*schema.Set))
+	if len(got) != 1 || got[0].Key != "site/index.html" {
+		t.Errorf("expected only site/index.html to be deleted, got %v", got)
+	}
+}
@@ -2,6 +2,10 @@ package ksyun
 
 import (
 	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
@@ -9,7 +13,11 @@ import (
 	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
 	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
 	"io"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -18,104 +26,187 @@ func resourceKsyunKs3BucketObject() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceKsyunKs3BucketObjectPut,
 		Read:   resourceKsyunKs3BucketObjectRead,
-		Update: resourceKsyunKs3BucketObjectPut,
+		Update: resourceKsyunKs3BucketObjectUpdate,
 		Delete: resourceKsyunKs3BucketObjectDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceKsyunKs3BucketObjectImport,
+		},
 
-		Schema: map[string]*schema.Schema{
-			"bucket": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
-			},
+		CustomizeDiff: resourceKsyunKs3BucketObjectCustomizeDiff,
 
-			"key": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceKsyunKs3BucketObjectV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceKsyunKs3BucketObjectStateUpgradeV0,
 			},
+		},
 
-			"source": {
-				Type:          schema.TypeString,
-				Optional:      true,
-				ConflictsWith: []string{"content"},
-			},
+		Schema: resourceKsyunKs3BucketObjectSchema(),
+	}
+}
 
-			"content": {
-				Type:          schema.TypeString,
-				Optional:      true,
-				ConflictsWith: []string{"source"},
-			},
+func resourceKsyunKs3BucketObjectSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"bucket": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
 
-			"acl": {
-				Type:         schema.TypeString,
-				Default:      ks3.ACLPrivate,
-				Optional:     true,
-				ValidateFunc: validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
-			},
+		"key": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
 
-			"content_type": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Computed: true,
-			},
+		"source": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"content", "content_base64"},
+		},
 
-			"content_length": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
+		"content": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"source", "content_base64"},
+		},
 
-			"cache_control": {
-				Type:     schema.TypeString,
-				Optional: true,
-			},
+		"content_base64": {
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"source", "content"},
+			ValidateFunc:  validation.StringIsBase64,
+		},
 
-			"content_disposition": {
-				Type:     schema.TypeString,
-				Optional: true,
-			},
+		"source_hash": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
 
-			"content_encoding": {
-				Type:     schema.TypeString,
-				Optional: true,
-			},
+		"acl": {
+			Type:             schema.TypeString,
+			Default:          ks3.ACLPrivate,
+			Optional:         true,
+			ValidateFunc:     validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+			DiffSuppressFunc: ks3ACLDiffSuppressFunc,
+		},
 
-			"content_md5": {
-				Type:     schema.TypeString,
-				Optional: true,
-			},
+		"grant": ks3GrantSchema(),
 
-			"expires": {
-				Type:     schema.TypeString,
-				Optional: true,
-			},
+		// Without a content_type the type is detected on every upload, and the type read back is kept as
+		// long as it is the one that would be detected.
+		"content_type": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: ks3ObjectContentTypeDiffSuppressFunc,
+		},
 
-			"server_side_encryption": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ValidateFunc: validation.StringInSlice([]string{
-					string(ServerSideEncryptionKMS), string(ServerSideEncryptionAes256),
-				}, false),
-				Default: ServerSideEncryptionAes256,
-			},
+		"content_length": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
 
-			"kms_key_id": {
-				Type:     schema.TypeString,
-				Optional: true,
-				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
-					return ServerSideEncryptionKMS != d.Get("server_side_encryption").(string)
-				},
-			},
+		"cache_control": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
 
-			"etag": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
+		"content_disposition": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+
+		"content_encoding": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+
+		"content_md5": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+
+		"expires": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
 
-			"version_id": {
-				Type:     schema.TypeString,
-				Computed: true,
+		"storage_class": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(ks3.StorageStandard), string(ks3.StorageIA), string(ks3.StorageArchive), string(ks3.StorageDeepIA),
+			}, false),
+		},
+
+		"tags": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		"metadata": {
+			Type:         schema.TypeMap,
+			Optional:     true,
+			Elem:         &schema.Schema{Type: schema.TypeString},
+			ValidateFunc: validateKs3ObjectMetadata,
+		},
+
+		"server_side_encryption": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ValidateFunc: validation.StringInSlice([]string{
+				string(ServerSideEncryptionKMS), string(ServerSideEncryptionAes256),
+			}, false),
+			Default: ServerSideEncryptionAes256,
+		},
+
+		"kms_key_id": {
+			Type:     schema.TypeString,
+			Optional: true,
+			DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+				return ServerSideEncryptionKMS != d.Get("server_side_encryption").(string)
 			},
 		},
+
+		"sse_customer_key": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			ValidateFunc: validateKs3SSECustomerKey,
+		},
+
+		"sse_customer_key_md5": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+
+		"etag": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+
+		"version_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+
+		"part_size": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      ks3DefaultPartSize,
+			ValidateFunc: validation.IntBetween(ks3.MinPartSize, ks3.MaxPartSize),
+		},
+
+		"upload_concurrency": {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      ks3DefaultUploadConcurrency,
+			ValidateFunc: validation.IntBetween(1, 100),
+		},
 	}
 }
 
@@ -133,6 +224,7 @@ func resourceKsyunKs3BucketObjectPut(d *schema.ResourceData, meta interface{}) e
 	bucket, _ := raw.(*ks3.Bucket)
 	var filePath string
 	var body io.Reader
+	var bodyMd5 string
 
 	if v, ok := d.GetOk("source"); ok {
 		source := v.(string)
@@ -142,32 +234,64 @@ func resourceKsyunKs3BucketObjectPut(d *schema.ResourceData, meta interface{}) e
 		}
 
 		filePath = path
-	} else if v, ok := d.GetOk("content"); ok {
-		content := v.(string)
-		body = bytes.NewReader([]byte(content))
+	} else if content, ok, err := ks3ObjectInlineContent(d); ok {
+		if err != nil {
+			return WrapError(err)
+		}
+		body = bytes.NewReader(content)
+		if _, ok := d.GetOk("content_base64"); ok {
+			sum := md5.Sum(content)
+			bodyMd5 = hex.EncodeToString(sum[:])
+		}
 	} else {
-		return WrapError(Error("[ERROR] Must specify \"source\" or \"content\" field"))
+		return WrapError(Error("[ERROR] Must specify \"source\", \"content\" or \"content_base64\" field"))
 	}
 
 	key := d.Get("key").(string)
 	options, err := buildObjectHeaderOptions(d)
+	if _, ok := d.GetOk("content_type"); !ok {
+		contentType, err := ks3ObjectDetectedContentType(d)
+		if err != nil {
+			return WrapError(err)
+		}
+		options = append(options, ks3.ContentType(contentType))
+	}
+
+	// A customer provided key replaces the server managed encryption.
+	partSize := int64(d.Get("part_size").(int))
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = append(options, ks3SSECOptions(v.(string))...)
+		// The SDK does not send the SSE-C headers with the parts of a multipart upload.
+		partSize = ks3.MaxPartSize
+		if filePath != "" {
+			if err := checkKs3SSECUploadSize(filePath); err != nil {
+				return WrapError(err)
+			}
+		}
+	} else {
+		if v, ok := d.GetOk("server_side_encryption"); ok {
+			options = append(options, ks3.ServerSideEncryption(v.(string)))
+		}
 
-	if v, ok := d.GetOk("server_side_encryption"); ok {
-		options = append(options, ks3.ServerSideEncryption(v.(string)))
+		if v, ok := d.GetOk("kms_key_id"); ok {
+			options = append(options, ks3.ServerSideEncryptionKeyID(v.(string)))
+		}
 	}
 
-	if v, ok := d.GetOk("kms_key_id"); ok {
-		options = append(options, ks3.ServerSideEncryptionKeyID(v.(string)))
+	if v, ok := d.GetOk("storage_class"); ok {
+		options = append(options, ks3.ObjectStorageClass(ks3.StorageClassType(v.(string))))
 	}
 
 	if err != nil {
 		return WrapError(err)
 	}
 	if filePath != "" {
-		err = bucket.PutObjectFromFile(key, filePath, options...)
+		err = uploadKs3ObjectFromFile(bucket, key, filePath, partSize, d.Get("upload_concurrency").(int), options)
 	}
 
-	if body != nil {
+	if body != nil && bodyMd5 != "" {
+		err = putKs3ObjectVerified(bucket, key, body, bodyMd5, options)
+	} else if body != nil {
 		err = bucket.PutObject(key, body, options...)
 	}
 
@@ -175,10 +299,236 @@ func resourceKsyunKs3BucketObjectPut(d *schema.ResourceData, meta interface{}) e
 		return WrapError(Error("Error putting object in Ks3 bucket (%#v): %s", bucket, err))
 	}
 
-	d.SetId(key)
+	d.SetId(ks3BucketObjectId(d.Get("bucket").(string), key))
+
+	// Uploading replaces the grants and tags of an existing object, so they are always written again.
+	if d.Get("grant").(*schema.Set).Len() > 0 {
+		if err := resourceKsyunKs3BucketObjectGrantUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+	}
+	if len(d.Get("tags").(map[string]interface{})) > 0 {
+		if err := resourceKsyunKs3BucketObjectTaggingUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+	}
+	return resourceKsyunKs3BucketObjectRead(d, meta)
+}
+
+// putKs3ObjectVerified uploads the body and checks that the ETag returned by KS3 matches the MD5 of the
+// data that was sent. The check is skipped for KMS and SSE-C encrypted objects, whose ETag is not an MD5.
+func putKs3ObjectVerified(bucket *ks3.Bucket, key string, body io.Reader, bodyMd5 string, options []ks3.Option) error {
+	resp, err := bucket.DoPutObject(&ks3.PutObjectRequest{ObjectKey: key, Reader: body}, options)
+	// The SDK can return the response together with an error, such as a failed CRC check.
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return verifyKs3PutObjectEtag(resp.Headers, key, bodyMd5)
+}
+
+func verifyKs3PutObjectEtag(header http.Header, key, bodyMd5 string) error {
+	if header.Get(ks3.HTTPHeaderKs3ServerSideEncryption) == ServerSideEncryptionKMS || header.Get(ks3.HTTPHeaderSSECKeyMd5) != "" {
+		return nil
+	}
+	if etag := strings.Trim(header.Get("ETag"), `"`); !strings.EqualFold(etag, bodyMd5) {
+		return fmt.Errorf("the ETag %q returned for the object %s does not match the MD5 %q of the uploaded content", etag, key, bodyMd5)
+	}
+	return nil
+}
+
+func resourceKsyunKs3BucketObjectUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	if d.HasChanges(ks3BucketObjectPutAttributes...) || d.HasChange("etag") {
+		return resourceKsyunKs3BucketObjectPut(d, meta)
+	}
+
+	if d.HasChange("storage_class") {
+		if err := resourceKsyunKs3BucketObjectStorageClassUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+	}
+
+	// The copy that changes the storage class resets the grants to the canned ACL.
+	if d.HasChange("grant") || (d.HasChange("storage_class") && d.Get("grant").(*schema.Set).Len() > 0) {
+		if err := resourceKsyunKs3BucketObjectGrantUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		if err := resourceKsyunKs3BucketObjectTaggingUpdate(client, d); err != nil {
+			return WrapError(err)
+		}
+	}
 	return resourceKsyunKs3BucketObjectRead(d, meta)
 }
 
+// resourceKsyunKs3BucketObjectGrantUpdate replaces the ACL of the object with the grant blocks, or with
+// the canned ACL once there are none left.
+func resourceKsyunKs3BucketObjectGrantUpdate(client *connectivity.KsyunClient, d *schema.ResourceData) error {
+	key := d.Get("key").(string)
+	grants := d.Get("grant").(*schema.Set).List()
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		if len(grants) == 0 {
+			acl := d.Get("acl").(string)
+			if acl == ks3ACLCustom {
+				acl = string(ks3.ACLPrivate)
+			}
+			return nil, bucket.SetObjectACL(key, ks3.ACLType(acl))
+		}
+		acl, err := bucket.GetObjectACL(key)
+		if err != nil {
+			return nil, err
+		}
+		policy, err := expandKs3Grants(acl.Owner.ID, grants)
+		if err != nil {
+			return nil, err
+		}
+		return policy, putKs3AccessControlPolicy(bucket, key, policy)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "PutObjectACL", KsyunKs3GoSdk)
+	}
+	addDebug("PutObjectACL", raw, requestInfo, map[string]string{"objectKey": key})
+	return nil
+}
+
+// resourceKsyunKs3BucketObjectStorageClassUpdate changes the storage class by copying the object
+// onto itself. The metadata is kept by the copy, while the ACL and encryption are sent again.
+func resourceKsyunKs3BucketObjectStorageClassUpdate(client *connectivity.KsyunClient, d *schema.ResourceData) error {
+	key := d.Get("key").(string)
+	options := []ks3.Option{
+		ks3.MetadataDirective(ks3.MetaCopy),
+		ks3.ObjectStorageClass(ks3.StorageClassType(d.Get("storage_class").(string))),
+	}
+	options = append(options, ks3ObjectACLOptions(d)...)
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = append(options, ks3CopySourceSSECOptions(v.(string))...)
+		options = append(options, ks3SSECOptions(v.(string))...)
+	} else {
+		if v, ok := d.GetOk("server_side_encryption"); ok {
+			options = append(options, ks3.ServerSideEncryption(v.(string)))
+		}
+		if v, ok := d.GetOk("kms_key_id"); ok && d.Get("server_side_encryption").(string) == ServerSideEncryptionKMS {
+			options = append(options, ks3.ServerSideEncryptionKeyID(v.(string)))
+		}
+	}
+
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.CopyObject(key, key, options...)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "CopyObject", KsyunKs3GoSdk)
+	}
+	addDebug("CopyObject", raw, requestInfo, map[string]interface{}{
+		"objectKey": key,
+		"options":   options,
+	})
+	return nil
+}
+
+// ks3ObjectDetectedContentType returns the content type detected from the key, the source file name and
+// the head of the source or inline content.
+func ks3ObjectDetectedContentType(d *schema.ResourceData) (string, error) {
+	var head []byte
+	var filePath string
+	if v, ok := d.GetOk("source"); ok {
+		path, err := homedir.Expand(v.(string))
+		if err != nil {
+			return "", err
+		}
+		filePath = path
+		if head, err = readKs3FileHead(path); err != nil {
+			return "", err
+		}
+	} else if content, ok, err := ks3ObjectInlineContent(d); ok {
+		if err != nil {
+			return "", err
+		}
+		head = content
+	}
+	return detectKs3ContentType(head, d.Get("key").(string), filePath), nil
+}
+
+// ks3ObjectInlineContent returns the bytes of content, or of content_base64 once decoded. ok is false
+// when the object is uploaded from a source file instead.
+func ks3ObjectInlineContent(d *schema.ResourceData) (content []byte, ok bool, err error) {
+	if v, ok := d.GetOk("content"); ok {
+		return []byte(v.(string)), true, nil
+	}
+	if v, ok := d.GetOk("content_base64"); ok {
+		content, err := base64.StdEncoding.DecodeString(v.(string))
+		if err != nil {
+			return nil, true, Error("Error decoding content_base64 of the Ks3 object %s: %s", d.Get("key").(string), err)
+		}
+		return content, true, nil
+	}
+	return nil, false, nil
+}
+
+// ks3ObjectContentTypeDiffSuppressFunc keeps the content type read back while content_type is not set
+// and the type matches the one detected for the current source. Otherwise, as when the extension of
+// the source changes, the object is uploaded again with the detected type.
+func ks3ObjectContentTypeDiffSuppressFunc(k, old, new string, d *schema.ResourceData) bool {
+	if new != "" || old == "" {
+		return new == old
+	}
+	detected, err := ks3ObjectDetectedContentType(d)
+	return err == nil && detected == old
+}
+
+// ks3ObjectACLOptions returns the canned ACL header of an upload or a copy. It is left out while grant
+// blocks define the ACL, and for the "custom" ACL read back from such grants, which KS3 does not accept
+// as a header. The grants are applied after the upload or copy instead.
+func ks3ObjectACLOptions(d *schema.ResourceData) []ks3.Option {
+	acl := d.Get("acl").(string)
+	if acl == "" || acl == ks3ACLCustom || d.Get("grant").(*schema.Set).Len() > 0 {
+		return nil
+	}
+	return []ks3.Option{ks3.ObjectACL(ks3.ACLType(acl))}
+}
+
+func resourceKsyunKs3BucketObjectTaggingUpdate(client *connectivity.KsyunClient, d *schema.ResourceData) error {
+	key := d.Get("key").(string)
+	tags := d.Get("tags").(map[string]interface{})
+	var requestInfo *ks3.Client
+	if len(tags) == 0 {
+		raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+			requestInfo = &bucket.Client
+			return nil, bucket.DeleteObjectTagging(key)
+		})
+		if err != nil && !ks3NotFoundError(err) {
+			return WrapErrorf(err, DefaultErrorMsg, d.Id(), "DeleteObjectTagging", KsyunKs3GoSdk)
+		}
+		addDebug("DeleteObjectTagging", raw, requestInfo, map[string]string{"objectKey": key})
+		return nil
+	}
+
+	tagging := ks3.Tagging{}
+	for k, v := range tags {
+		tagging.Tags = append(tagging.Tags, ks3.Tag{Key: k, Value: v.(string)})
+	}
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return nil, bucket.PutObjectTagging(key, tagging)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "PutObjectTagging", KsyunKs3GoSdk)
+	}
+	addDebug("PutObjectTagging", raw, requestInfo, map[string]interface{}{
+		"objectKey": key,
+		"tagging":   tagging,
+	})
+	return nil
+}
+
 func resourceKsyunKs3BucketObjectRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*connectivity.KsyunClient)
 	var requestInfo *ks3.Client
@@ -195,12 +545,17 @@ func resourceKsyunKs3BucketObjectRead(d *schema.ResourceData, meta interface{})
 	if err != nil {
 		return WrapError(err)
 	}
+	// Objects encrypted with a customer provided key cannot be read without it.
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = append(options, ks3SSECOptions(v.(string))...)
+	}
 
 	object, err := bucket.GetObjectDetailedMeta(d.Get("key").(string), options...)
 	if err != nil {
-		if IsExpectedErrors(err, []string{"404 Not Found"}) {
+		if ks3NotFoundError(err) || IsExpectedErrors(err, []string{"404 Not Found"}) {
+			log.Printf("[WARN] Ks3 object %s not found in the bucket %s, removing from state", d.Get("key").(string), d.Get("bucket").(string))
 			d.SetId("")
-			return WrapError(Error("To get the Object: %#v but it is not exist in the specified bucket %s.", d.Get("key").(string), d.Get("bucket").(string)))
+			return nil
 		}
 		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectDetailedMeta", KsyunKs3GoSdk)
 	}
@@ -215,7 +570,48 @@ func resourceKsyunKs3BucketObjectRead(d *schema.ResourceData, meta interface{})
 	d.Set("content_disposition", object.Get("Content-Disposition"))
 	d.Set("content_encoding", object.Get("Content-Encoding"))
 	d.Set("expires", object.Get("Expires"))
+	if err := d.Set("metadata", ks3ObjectMetadata(object)); err != nil {
+		return WrapError(err)
+	}
 	d.Set("etag", strings.Trim(object.Get("ETag"), `"`))
+	d.Set("sse_customer_key_md5", object.Get(ks3.HTTPHeaderSSECKeyMd5))
+	if object.Get(ks3.HTTPHeaderSSECKeyMd5) == "" {
+		d.Set("server_side_encryption", object.Get(ks3.HTTPHeaderKs3ServerSideEncryption))
+		d.Set("kms_key_id", object.Get(ks3.HTTPHeaderKs3ServerSideEncryptionKeyID))
+	}
+	// The storage class header is omitted for objects in the standard class.
+	if storageClass := object.Get(ks3.HTTPHeaderKs3StorageClass); storageClass != "" {
+		d.Set("storage_class", storageClass)
+	} else {
+		d.Set("storage_class", string(ks3.StorageStandard))
+	}
+
+	raw, err = client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectACL(d.Get("key").(string))
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectACL", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectACL", raw, requestInfo, map[string]string{"objectKey": d.Get("key").(string)})
+	acl, _ := raw.(ks3.GetObjectACLResult)
+	d.Set("acl", ks3CannedACL(acl))
+	if err := d.Set("grant", ks3ReadGrants(d, acl)); err != nil {
+		return WrapError(err)
+	}
+
+	raw, err = client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectTagging(d.Get("key").(string))
+	})
+	if err != nil && !ks3NotFoundError(err) {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectTagging", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectTagging", raw, requestInfo, map[string]string{"objectKey": d.Get("key").(string)})
+	tagging, _ := raw.(ks3.GetObjectTaggingResult)
+	if err := d.Set("tags", ks3TagsToMap(tagging.Tags)); err != nil {
+		return WrapError(err)
+	}
 
 	return nil
 }
@@ -234,7 +630,8 @@ func resourceKsyunKs3BucketObjectDelete(d *schema.ResourceData, meta interface{}
 	addDebug("Bucket", raw, requestInfo, map[string]string{"bucketName": d.Get("bucket").(string)})
 	bucket, _ := raw.(*ks3.Bucket)
 
-	err = bucket.DeleteObject(d.Id())
+	key := d.Get("key").(string)
+	err = bucket.DeleteObject(key)
 	if err != nil {
 		if IsExpectedErrors(err, []string{"No Content", "Not Found"}) {
 			return nil
@@ -242,15 +639,305 @@ func resourceKsyunKs3BucketObjectDelete(d *schema.ResourceData, meta interface{}
 		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "DeleteObject", KsyunKs3GoSdk)
 	}
 
-	return WrapError(ks3Service.WaitForKs3BucketObject(bucket, d.Id(), Deleted, DefaultTimeoutMedium))
+	return WrapError(ks3Service.WaitForKs3BucketObject(bucket, key, Deleted, DefaultTimeoutMedium))
 
 }
 
-func buildObjectHeaderOptions(d *schema.ResourceData) (options []ks3.Option, err error) {
+// ks3BucketObjectId returns the resource ID of an object, which is the bucket name and the object key
+// separated by a slash.
+func ks3BucketObjectId(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// parseKs3BucketObjectId splits an ID made by ks3BucketObjectId. Bucket names never contain a slash,
+// so everything after the first one is the key.
+func parseKs3BucketObjectId(id string) (bucket, key string, err error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", WrapError(Error("invalid ks3 bucket object ID %q, expected <bucket>/<key>", id))
+	}
+	return parts[0], parts[1], nil
+}
+
+func resourceKsyunKs3BucketObjectImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	bucket, key, err := parseKs3BucketObjectId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.Set("bucket", bucket)
+	d.Set("key", key)
+	// Upload settings are not stored on the object, so they start from their defaults.
+	d.Set("part_size", ks3DefaultPartSize)
+	d.Set("upload_concurrency", ks3DefaultUploadConcurrency)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceKsyunKs3BucketObjectStateUpgradeV0 replaces the object key used as ID by schema version 0
+// with the bucket-qualified ID.
+func resourceKsyunKs3BucketObjectStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		return rawState, nil
+	}
+	bucket, _ := rawState["bucket"].(string)
+	key, _ := rawState["key"].(string)
+	if bucket != "" && key != "" {
+		rawState["id"] = ks3BucketObjectId(bucket, key)
+	}
+	// Objects created before the multipart upload settings existed have no value for them.
+	if v, ok := rawState["part_size"]; !ok || v == nil {
+		rawState["part_size"] = ks3DefaultPartSize
+	}
+	if v, ok := rawState["upload_concurrency"]; !ok || v == nil {
+		rawState["upload_concurrency"] = ks3DefaultUploadConcurrency
+	}
+	return rawState, nil
+}
 
-	if v, ok := d.GetOk("acl"); ok {
-		options = append(options, ks3.ObjectACL(ks3.ACLType(v.(string))))
+// resourceKsyunKs3BucketObjectV0 is the schema of version 0 of the state, which used the object key as
+// ID. It only decodes old state and must not follow later changes of the schema.
+func resourceKsyunKs3BucketObjectV0() *schema.Resource {
+	optional := func(t schema.ValueType) *schema.Schema {
+		return &schema.Schema{Type: t, Optional: true}
+	}
+	optionalComputed := func(t schema.ValueType) *schema.Schema {
+		return &schema.Schema{Type: t, Optional: true, Computed: true}
+	}
+	computed := func(t schema.ValueType) *schema.Schema {
+		return &schema.Schema{Type: t, Computed: true}
+	}
+	stringMap := func() *schema.Schema {
+		return &schema.Schema{Type: schema.TypeMap, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}}
+	}
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"bucket":                 {Type: schema.TypeString, Required: true, ForceNew: true},
+			"key":                    {Type: schema.TypeString, Required: true, ForceNew: true},
+			"source":                 optional(schema.TypeString),
+			"content":                optional(schema.TypeString),
+			"source_hash":            optional(schema.TypeString),
+			"acl":                    optional(schema.TypeString),
+			"content_type":           optionalComputed(schema.TypeString),
+			"content_length":         computed(schema.TypeString),
+			"cache_control":          optional(schema.TypeString),
+			"content_disposition":    optional(schema.TypeString),
+			"content_encoding":       optional(schema.TypeString),
+			"content_md5":            optional(schema.TypeString),
+			"expires":                optional(schema.TypeString),
+			"storage_class":          optionalComputed(schema.TypeString),
+			"tags":                   stringMap(),
+			"metadata":               stringMap(),
+			"server_side_encryption": optional(schema.TypeString),
+			"kms_key_id":             optional(schema.TypeString),
+			"etag":                   computed(schema.TypeString),
+			"version_id":             computed(schema.TypeString),
+			"part_size":              optional(schema.TypeInt),
+			"upload_concurrency":     optional(schema.TypeInt),
+		},
 	}
+}
+
+// resourceKsyunKs3BucketObjectCustomizeDiff compares the ETag expected for the local source or content with
+// the ETag of the stored object. A difference means either the local data or the object changed since the
+// last apply, so the etag is marked as unknown and the object is uploaded again.
+func resourceKsyunKs3BucketObjectCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	for _, key := range ks3BucketObjectPutAttributes {
+		if d.HasChange(key) {
+			return nil
+		}
+	}
+	etag := d.Get("etag").(string)
+	// The ETag of a KMS or SSE-C encrypted object is not derived from the MD5 of its data.
+	if etag == "" || d.Get("server_side_encryption").(string) == ServerSideEncryptionKMS || d.Get("sse_customer_key").(string) != "" {
+		return nil
+	}
+	if !d.NewValueKnown("source") || !d.NewValueKnown("content") || !d.NewValueKnown("content_base64") {
+		return nil
+	}
+
+	// The ETag of a multipart upload depends on the part size, while the ETag of an object uploaded with
+	// a single request is the MD5 of its data, whatever part_size was set to.
+	var partSize int64
+	if strings.Contains(etag, "-") {
+		partSize = int64(d.Get("part_size").(int))
+	}
+	localEtag, err := ks3LocalObjectEtag(d.Get("source").(string), d.Get("content").(string),
+		d.Get("content_base64").(string), partSize)
+	if os.IsNotExist(err) {
+		// The source may only be built during the apply.
+		log.Printf("[DEBUG] Ks3 object %s: skipping the ETag comparison, %s", d.Id(), err)
+		return nil
+	}
+	if err != nil {
+		return WrapError(err)
+	}
+	if localEtag != "" && !strings.EqualFold(localEtag, etag) {
+		log.Printf("[DEBUG] Ks3 object %s: local ETag %s differs from the stored ETag %s", d.Id(), localEtag, etag)
+		return d.SetNewComputed("etag")
+	}
+	return nil
+}
+
+// ks3LocalObjectEtag returns the ETag KS3 assigns to the object once it is uploaded from the source file
+// or the inline content, or an empty string when none is set.
+func ks3LocalObjectEtag(source, content, contentBase64 string, partSize int64) (string, error) {
+	switch {
+	case source != "":
+		path, err := homedir.Expand(source)
+		if err != nil {
+			return "", err
+		}
+		return ks3FileEtag(path, partSize)
+	case content != "":
+		// Inline content is always uploaded with a single request, whatever its size.
+		sum := md5.Sum([]byte(content))
+		return hex.EncodeToString(sum[:]), nil
+	case contentBase64 != "":
+		data, err := base64.StdEncoding.DecodeString(contentBase64)
+		if err != nil {
+			return "", err
+		}
+		sum := md5.Sum(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return "", nil
+}
+
+// ks3FileEtag returns the ETag KS3 assigns to the file when it is uploaded by uploadKs3ObjectFromFile.
+func ks3FileEtag(filePath string, partSize int64) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	return ks3Etag(file, info.Size(), partSize)
+}
+
+// ks3Etag returns the hex MD5 of data up to partSize bytes long. Larger data is uploaded in parts, and
+// its ETag is the MD5 of the concatenated part MD5s followed by the number of parts.
+func ks3Etag(data io.Reader, size, partSize int64) (string, error) {
+	if partSize <= 0 || size <= partSize {
+		hash := md5.New()
+		if _, err := io.Copy(hash, data); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	var sums []byte
+	parts := 0
+	for ; int64(parts)*partSize < size; parts++ {
+		hash := md5.New()
+		if _, err := io.CopyN(hash, data, partSize); err != nil && err != io.EOF {
+			return "", err
+		}
+		sums = append(sums, hash.Sum(nil)...)
+	}
+	sum := md5.Sum(sums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), parts), nil
+}
+
+// ks3BucketObjectPutAttributes are the arguments whose change requires the object to be uploaded again.
+var ks3BucketObjectPutAttributes = []string{
+	"source", "source_hash", "content", "content_base64", "acl", "content_type", "cache_control", "content_disposition",
+	"content_encoding", "content_md5", "expires", "metadata", "server_side_encryption", "kms_key_id",
+	"sse_customer_key",
+}
+
+const ks3DefaultPartSize = 64 * 1024 * 1024
+const ks3DefaultUploadConcurrency = 3
+const ks3UploadRetryCount = 3
+
+// uploadKs3ObjectFromFile uploads files larger than partSize with a parallel multipart upload. The progress
+// is recorded in a checkpoint file, so a failed attempt or an interrupted apply resumes with the parts
+// that are still missing. When every attempt fails, the multipart upload is aborted.
+func uploadKs3ObjectFromFile(bucket *ks3.Bucket, key, filePath string, partSize int64, routines int, options []ks3.Option) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return WrapError(err)
+	}
+	if info.Size() <= partSize {
+		return bucket.PutObjectFromFile(key, filePath, options...)
+	}
+
+	checkpoint, err := ks3UploadCheckpointPath(bucket.BucketName, key, filePath)
+	if err != nil {
+		return WrapError(err)
+	}
+	// The MD5 of the whole file does not apply to the individual parts.
+	options = ks3.DeleteOption(options, ks3.HTTPHeaderContentMD5)
+	options = append(options, ks3.Routines(routines), ks3.Checkpoint(true, checkpoint))
+	for retry := 1; ; retry++ {
+		err = bucket.UploadFile(key, filePath, partSize, options...)
+		if err == nil {
+			return nil
+		}
+		if retry >= ks3UploadRetryCount {
+			break
+		}
+		log.Printf("[WARN] Multipart upload of %s to %s/%s failed (attempt %d), resuming from the checkpoint: %v", filePath, bucket.BucketName, key, retry, err)
+		time.Sleep(time.Duration(retry) * 5 * time.Second)
+	}
+	abortKs3Upload(bucket, checkpoint)
+	return err
+}
+
+// checkKs3SSECUploadSize fails before anything is sent when a file encrypted with a customer provided key
+// is too large for a single upload, as it cannot be uploaded in parts.
+func checkKs3SSECUploadSize(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if info.Size() > ks3.MaxPartSize {
+		return Error("The source %s is %d bytes, files encrypted with sse_customer_key can be at most %d bytes",
+			filePath, info.Size(), int64(ks3.MaxPartSize))
+	}
+	return nil
+}
+
+func ks3UploadCheckpointPath(bucketName, key, filePath string) (string, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(os.TempDir(), "terraform-provider-ks3")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(fmt.Sprintf("%s\nks3://%s/%s", absPath, bucketName, key)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".cp"), nil
+}
+
+// abortKs3Upload aborts the multipart upload recorded in the checkpoint file and removes the file.
+func abortKs3Upload(bucket *ks3.Bucket, checkpoint string) {
+	defer os.Remove(checkpoint)
+	data, err := ioutil.ReadFile(checkpoint)
+	if err != nil {
+		return
+	}
+	var cp struct {
+		ObjectKey string
+		UploadID  string
+	}
+	if err := json.Unmarshal(data, &cp); err != nil || cp.UploadID == "" {
+		return
+	}
+	imur := ks3.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: cp.ObjectKey, UploadID: cp.UploadID}
+	if err := bucket.AbortMultipartUpload(imur); err != nil {
+		log.Printf("[WARN] Unable to abort the multipart upload %s of %s/%s: %v", cp.UploadID, bucket.BucketName, cp.ObjectKey, err)
+	}
+}
+
+func buildObjectHeaderOptions(d *schema.ResourceData) (options []ks3.Option, err error) {
+
+	options = append(options, ks3ObjectACLOptions(d)...)
 
 	if v, ok := d.GetOk("content_type"); ok {
 		options = append(options, ks3.ContentType(v.(string)))
@@ -281,6 +968,10 @@ func buildObjectHeaderOptions(d *schema.ResourceData) (options []ks3.Option, err
 		options = append(options, ks3.Expires(expiresTime))
 	}
 
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		options = append(options, ks3.Meta(k, v.(string)))
+	}
+
 	if options == nil || len(options) == 0 {
 		log.Printf("[WARN] Object header options is nil.")
 	}
@@ -0,0 +1,337 @@
+package ksyun
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+func TestKs3Etag(t *testing.T) {
+	cases := []struct {
+		data     string
+		partSize int64
+		etag     string
+	}{
+		{"", 5, "d41d8cd98f00b204e9800998ecf8427e"},
+		{"hello", 5, "5d41402abc4b2a76b9719d911017c592"},
+		// md5(md5("hello") + md5(" worl") + md5("d")) followed by the number of parts.
+		{"hello world", 5, "df349a9519959b17a605009540f4b31d-3"},
+	}
+	for _, c := range cases {
+		etag, err := ks3Etag(strings.NewReader(c.data), int64(len(c.data)), c.partSize)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", c.data, err)
+		}
+		if etag != c.etag {
+			t.Fatalf("expected ETag %s for %q, got %s", c.etag, c.data, etag)
+		}
+	}
+}
+
+func TestParseKs3BucketObjectId(t *testing.T) {
+	bucket, key, err := parseKs3BucketObjectId("my-bucket/path/to/object.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bucket != "my-bucket" || key != "path/to/object.txt" {
+		t.Fatalf("got bucket %q and key %q", bucket, key)
+	}
+
+	for _, id := range []string{"object.txt", "/object.txt", "my-bucket/"} {
+		if _, _, err := parseKs3BucketObjectId(id); err == nil {
+			t.Errorf("expected an error for the ID %q", id)
+		}
+	}
+}
+
+// ks3BucketObjectV0State is the state of an object written by a provider release with schema version 0.
+const ks3BucketObjectV0State = `{
+	"id": "path/to/object.txt",
+	"bucket": "my-bucket",
+	"key": "path/to/object.txt",
+	"source": null,
+	"content": "hello",
+	"acl": "private",
+	"content_type": "text/plain",
+	"content_length": "5",
+	"cache_control": "",
+	"content_disposition": "",
+	"content_encoding": "",
+	"content_md5": "",
+	"expires": "",
+	"server_side_encryption": "AES256",
+	"kms_key_id": null,
+	"etag": "5d41402abc4b2a76b9719d911017c592",
+	"version_id": ""
+}`
+
+func TestResourceKsyunKs3BucketObjectStateUpgradeV0(t *testing.T) {
+	// The frozen v0 schema decodes the state as written by the old release.
+	if _, err := ctyjson.Unmarshal([]byte(ks3BucketObjectV0State), resourceKsyunKs3BucketObjectV0().CoreConfigSchema().ImpliedType()); err != nil {
+		t.Fatalf("unable to decode the v0 state: %s", err)
+	}
+
+	var rawState map[string]interface{}
+	if err := json.Unmarshal([]byte(ks3BucketObjectV0State), &rawState); err != nil {
+		t.Fatal(err)
+	}
+	upgraded, err := resourceKsyunKs3BucketObjectStateUpgradeV0(rawState, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if upgraded["id"] != "my-bucket/path/to/object.txt" {
+		t.Fatalf("got ID %q", upgraded["id"])
+	}
+	if upgraded["part_size"] != ks3DefaultPartSize || upgraded["upload_concurrency"] != ks3DefaultUploadConcurrency {
+		t.Fatalf("expected the default upload settings, got part_size %v and upload_concurrency %v",
+			upgraded["part_size"], upgraded["upload_concurrency"])
+	}
+
+	// The upgraded state decodes with the current schema.
+	data, err := json.Marshal(upgraded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctyjson.Unmarshal(data, resourceKsyunKs3BucketObject().CoreConfigSchema().ImpliedType()); err != nil {
+		t.Fatalf("unable to decode the upgraded state: %s", err)
+	}
+}
+
+func TestBuildObjectHeaderOptionsACL(t *testing.T) {
+	grant := []interface{}{map[string]interface{}{"id": "", "uri": ks3.ALL_USERS, "permission": "READ"}}
+	cases := []struct {
+		raw map[string]interface{}
+		acl interface{}
+	}{
+		{map[string]interface{}{"acl": "public-read"}, "public-read"},
+		{map[string]interface{}{"acl": "public-read", "grant": grant}, nil},
+		{map[string]interface{}{"acl": ks3ACLCustom}, nil},
+	}
+	for i, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceKsyunKs3BucketObjectSchema(), c.raw)
+		options, err := buildObjectHeaderOptions(d)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %s", i, err)
+		}
+		_, acl, _ := ks3.IsOptionSet(options, ks3.HTTPHeaderKs3ObjectACL)
+		if acl != c.acl {
+			t.Errorf("case %d: expected the ACL header %v, got %v", i, c.acl, acl)
+		}
+	}
+}
+
+func TestKs3LocalObjectEtag(t *testing.T) {
+	// Inline content larger than the part size is still uploaded with a single PUT.
+	etag, err := ks3LocalObjectEtag("", "hello world", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != "5eb63bbbe01eeed093cb22bb8f5acdc3" {
+		t.Errorf("unexpected ETag %s for content", etag)
+	}
+
+	etag, err = ks3LocalObjectEtag("", "", "aGVsbG8gd29ybGQ=", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != "5eb63bbbe01eeed093cb22bb8f5acdc3" {
+		t.Errorf("unexpected ETag %s for content_base64", etag)
+	}
+
+	if etag, err := ks3LocalObjectEtag("", "", "", 5); err != nil || etag != "" {
+		t.Errorf("expected no ETag without content, got %q, %v", etag, err)
+	}
+}
+
+func TestResourceKsyunKs3BucketObjectCustomizeDiffEtag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ks3-etag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "artifact.bin")
+	data := bytes.Repeat([]byte("a"), 3*ks3.MinPartSize)
+	if err := ioutil.WriteFile(source, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	md5Etag := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		source   string
+		etag     string
+		partSize int
+		changed  bool
+	}{
+		// The source is only built during the apply.
+		{filepath.Join(dir, "missing.bin"), md5Etag, ks3DefaultPartSize, false},
+		// An object uploaded with a single request keeps its ETag once part_size is lowered.
+		{source, md5Etag, ks3.MinPartSize, false},
+		{source, "00000000000000000000000000000000", ks3DefaultPartSize, true},
+	}
+	r := resourceKsyunKs3BucketObject()
+	for i, c := range cases {
+		state := &terraform.InstanceState{
+			ID: "artifact.bin",
+			Attributes: map[string]string{
+				"id":                     "artifact.bin",
+				"bucket":                 "my-bucket",
+				"key":                    "artifact.bin",
+				"source":                 c.source,
+				"acl":                    "private",
+				"server_side_encryption": ServerSideEncryptionAes256,
+				"etag":                   c.etag,
+				"part_size":              fmt.Sprint(c.partSize),
+				"upload_concurrency":     fmt.Sprint(ks3DefaultUploadConcurrency),
+			},
+		}
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"bucket":    "my-bucket",
+			"key":       "artifact.bin",
+			"source":    c.source,
+			"part_size": c.partSize,
+		})
+		diff, err := r.Diff(state, config, nil)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+			continue
+		}
+		changed := diff != nil && diff.Attributes["etag"] != nil
+		if changed != c.changed {
+			t.Errorf("case %d: expected an etag change: %t, got the diff %v", i, c.changed, diff)
+		}
+	}
+}
+
+func TestKs3ObjectContentTypeDiffSuppressFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ks3-content-type")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"index.html", "logo.png"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		source   string
+		key      string
+		old      string
+		new      string
+		suppress bool
+	}{
+		// The stored type is the detected one.
+		{"index.html", "site/index", "text/html; charset=utf-8", "", true},
+		// The source changed from index.html to logo.png.
+		{"logo.png", "site/index", "text/html; charset=utf-8", "", false},
+		// An object stuck on the default type of KS3 is uploaded again.
+		{"index.html", "site/index", "application/octet-stream", "", false},
+		// A configured type always wins.
+		{"index.html", "site/index", "text/html; charset=utf-8", "text/plain", false},
+	}
+	for i, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceKsyunKs3BucketObjectSchema(), map[string]interface{}{
+			"bucket": "my-bucket",
+			"key":    c.key,
+			"source": filepath.Join(dir, c.source),
+		})
+		if got := ks3ObjectContentTypeDiffSuppressFunc("content_type", c.old, c.new, d); got != c.suppress {
+			t.Errorf("case %d: expected suppress %t, got %t", i, c.suppress, got)
+		}
+	}
+}
+
+func TestCheckKs3SSECUploadSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ks3-ssec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	small := filepath.Join(dir, "small")
+	if err := ioutil.WriteFile(small, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKs3SSECUploadSize(small); err != nil {
+		t.Errorf("unexpected error for a small file: %s", err)
+	}
+
+	// A sparse file is enough, the size is all that is checked.
+	large := filepath.Join(dir, "large")
+	file, err := os.Create(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = file.Truncate(ks3.MaxPartSize + 1)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKs3SSECUploadSize(large); err == nil || !strings.Contains(err.Error(), "sse_customer_key") {
+		t.Errorf("expected the size error for a file larger than ks3.MaxPartSize, got %v", err)
+	}
+}
+
+func TestKs3ObjectInlineContent(t *testing.T) {
+	cases := []struct {
+		raw     map[string]interface{}
+		content []byte
+		ok      bool
+		err     bool
+	}{
+		{map[string]interface{}{"content": "hello"}, []byte("hello"), true, false},
+		{map[string]interface{}{"content_base64": "AAH+/w=="}, []byte{0x00, 0x01, 0xfe, 0xff}, true, false},
+		{map[string]interface{}{"content_base64": "not base64!"}, nil, true, true},
+		{map[string]interface{}{"source": "/tmp/object.bin"}, nil, false, false},
+	}
+	for i, c := range cases {
+		c.raw["bucket"] = "my-bucket"
+		c.raw["key"] = "object.bin"
+		d := schema.TestResourceDataRaw(t, resourceKsyunKs3BucketObjectSchema(), c.raw)
+		content, ok, err := ks3ObjectInlineContent(d)
+		if ok != c.ok || (err != nil) != c.err || string(content) != string(c.content) {
+			t.Errorf("case %d: got %v, %t, %v, expected %v, %t, error %t", i, content, ok, err, c.content, c.ok, c.err)
+		}
+	}
+}
+
+func TestVerifyKs3PutObjectEtag(t *testing.T) {
+	const bodyMd5 = "5d41402abc4b2a76b9719d911017c592"
+	header := func(pairs ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(pairs); i += 2 {
+			h.Set(pairs[i], pairs[i+1])
+		}
+		return h
+	}
+	cases := []struct {
+		header http.Header
+		err    bool
+	}{
+		{header("ETag", `"5D41402ABC4B2A76B9719D911017C592"`), false},
+		{header("ETag", `"00000000000000000000000000000000"`), true},
+		{header(), true},
+		{header("ETag", `"kms-etag"`, ks3.HTTPHeaderKs3ServerSideEncryption, ServerSideEncryptionKMS), false},
+		{header("ETag", `"ssec-etag"`, ks3.HTTPHeaderSSECKeyMd5, "key-md5"), false},
+	}
+	for i, c := range cases {
+		if err := verifyKs3PutObjectEtag(c.header, "hello.txt", bodyMd5); (err != nil) != c.err {
+			t.Errorf("case %d: verifyKs3PutObjectEtag(%v) = %v, expected an error: %t", i, c.header, err, c.err)
+		}
+	}
+}
@@ -313,6 +313,42 @@ func TestKsyunKs3BucketLifecycleComplexConfig(t *testing.T) {
 	})
 }
 
+func TestKsyunKs3BucketForceDestroy(t *testing.T) {
+	var v ks3.GetBucketInfoResult
+
+	resourceId := "ksyun_ks3_bucket.default"
+	ra := resourceAttrInit(resourceId, ks3BucketBasicMap)
+
+	serviceFunc := func() interface{} {
+		return &Ks3Service{testAccProvider.Meta().(*connectivity.KsyunClient)}
+	}
+	rc := resourceCheckInit(resourceId, &v, serviceFunc)
+
+	rac := resourceAttrCheckInit(rc, ra)
+
+	testAccCheck := rac.resourceAttrMapUpdateSet()
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		IDRefreshName: resourceId,
+		Providers:     testAccProviders,
+		// 资源销毁后校验
+		CheckDestroy: rac.checkResourceDestroy(),
+		Steps: []resource.TestStep{
+			{
+				Config: bucketForceDestroyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheck(map[string]string{
+						"bucket":        "terraform-test-bucket-force-destroy",
+						"force_destroy": "true",
+					}),
+				),
+			},
+		},
+	})
+}
+
 const bucketACLConfig = `
 resource "ksyun_ks3_bucket" "default"{
   bucket = "terraform-test-bucket-acl"
@@ -463,6 +499,19 @@ resource "ksyun_ks3_bucket" "default" {
 }
 `
 
+const bucketForceDestroyConfig = `
+resource "ksyun_ks3_bucket" "default" {
+  bucket = "terraform-test-bucket-force-destroy"
+  force_destroy = true
+}
+
+resource "ksyun_ks3_bucket_object" "default" {
+  bucket = ksyun_ks3_bucket.default.bucket
+  key = "force-destroy.txt"
+  content = "force destroy"
+}
+`
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("KS3_TEST_ACCESS_KEY_ID"); v == "" {
 		t.Fatal("KS3_TEST_ACCESS_KEY_ID must be set for acceptance tests")
@@ -0,0 +1,456 @@
+package ksyun
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ks3CopyRoutines = 3
+
+func resourceKsyunKs3ObjectCopy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKsyunKs3ObjectCopyCreate,
+		Read:   resourceKsyunKs3ObjectCopyRead,
+		Update: resourceKsyunKs3ObjectCopyUpdate,
+		Delete: resourceKsyunKs3ObjectCopyDelete,
+
+		CustomizeDiff: resourceKsyunKs3ObjectCopyCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"key": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"source_bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"source_key": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"source_version_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"metadata_directive": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(ks3.MetaCopy),
+				ValidateFunc: validation.StringInSlice([]string{string(ks3.MetaCopy), string(ks3.MetaReplace)}, false),
+			},
+
+			"acl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      ks3.ACLPrivate,
+				ValidateFunc: validation.StringInSlice([]string{"private", "public-read", "public-read-write"}, false),
+			},
+
+			"storage_class": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					string(ks3.StorageStandard), string(ks3.StorageIA), string(ks3.StorageArchive), string(ks3.StorageDeepIA),
+				}, false),
+			},
+
+			"server_side_encryption": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					string(ServerSideEncryptionKMS), string(ServerSideEncryptionAes256),
+				}, false),
+			},
+
+			"kms_key_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return ServerSideEncryptionKMS != d.Get("server_side_encryption").(string)
+				},
+			},
+
+			"source_sse_customer_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ValidateFunc: validateKs3SSECustomerKey,
+			},
+
+			"sse_customer_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				ValidateFunc: validateKs3SSECustomerKey,
+			},
+
+			"sse_customer_key_md5": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			// The following headers can only be set with the REPLACE metadata directive. With COPY they
+			// are taken from the source object and read back.
+			"content_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"cache_control": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"content_disposition": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"content_encoding": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"expires": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"metadata": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Computed:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validateKs3ObjectMetadata,
+			},
+
+			"part_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      ks3DefaultPartSize,
+				ValidateFunc: validation.IntBetween(ks3.MinPartSize, ks3.MaxPartSize),
+			},
+
+			"content_length": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"etag": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"version_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"last_modified": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+var ks3ObjectCopyAttributes = []string{
+	"source_bucket", "source_key", "source_version_id", "metadata_directive", "acl", "storage_class",
+	"server_side_encryption", "kms_key_id", "content_type", "cache_control", "content_disposition",
+	"content_encoding", "expires", "metadata", "source_sse_customer_key", "sse_customer_key",
+}
+
+func resourceKsyunKs3ObjectCopyCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	options, err := buildObjectCopyOptions(d)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	sourceBucketName := d.Get("source_bucket").(string)
+	sourceKey := d.Get("source_key").(string)
+	var headOptions []ks3.Option
+	if v, ok := d.GetOk("source_version_id"); ok {
+		headOptions = append(headOptions, ks3.VersionId(v.(string)))
+	}
+	if v, ok := d.GetOk("source_sse_customer_key"); ok {
+		headOptions = append(headOptions, ks3SSECOptions(v.(string))...)
+	}
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(sourceBucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectDetailedMeta(sourceKey, headOptions...)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, ks3BucketObjectId(sourceBucketName, sourceKey), "GetObjectDetailedMeta", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectDetailedMeta", raw, requestInfo, map[string]string{"bucketName": sourceBucketName, "objectKey": sourceKey})
+	source, _ := raw.(http.Header)
+	size, err := strconv.ParseInt(source.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return WrapError(err)
+	}
+
+	bucketName := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+	partSize := int64(d.Get("part_size").(int))
+	// The SDK does not send the SSE-C headers with the parts of a multipart copy.
+	_, sourceSSEC := d.GetOk("source_sse_customer_key")
+	_, destinationSSEC := d.GetOk("sse_customer_key")
+	multipart := size > partSize && !sourceSSEC && !destinationSSEC
+	if multipart {
+		// A multipart copy creates the object from scratch, so copied headers have to be sent explicitly.
+		if d.Get("metadata_directive").(string) == string(ks3.MetaCopy) {
+			options = append(options, ks3ObjectCopyHeaderOptions(source)...)
+		}
+		options = ks3.DeleteOption(options, ks3.HTTPHeaderKs3MetadataDirective)
+		options = append(options, ks3.Routines(ks3CopyRoutines))
+		log.Printf("[DEBUG] Copying %s to %s with a multipart copy of %d byte parts",
+			ks3BucketObjectId(sourceBucketName, sourceKey), ks3BucketObjectId(bucketName, key), partSize)
+	}
+	raw, err = client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		if multipart {
+			return nil, bucket.CopyFile(sourceBucketName, sourceKey, key, partSize, options...)
+		}
+		return bucket.CopyObjectFrom(sourceBucketName, sourceKey, key, options...)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, ks3BucketObjectId(bucketName, key), "CopyObject", KsyunKs3GoSdk)
+	}
+	addDebug("CopyObject", raw, requestInfo, map[string]interface{}{
+		"sourceBucket": sourceBucketName,
+		"sourceKey":    sourceKey,
+		"objectKey":    key,
+		"options":      options,
+	})
+
+	d.SetId(ks3BucketObjectId(bucketName, key))
+	return resourceKsyunKs3ObjectCopyRead(d, meta)
+}
+
+func resourceKsyunKs3ObjectCopyRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	var requestInfo *ks3.Client
+	key := d.Get("key").(string)
+	var options []ks3.Option
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = ks3SSECOptions(v.(string))
+	}
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectDetailedMeta(key, options...)
+	})
+	if err != nil {
+		if ks3NotFoundError(err) {
+			log.Printf("[WARN] Ks3 object %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectDetailedMeta", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectDetailedMeta", raw, requestInfo, map[string]string{"objectKey": key})
+	object, _ := raw.(http.Header)
+	if err := flattenKs3ObjectCopyHeaders(d, object); err != nil {
+		return WrapError(err)
+	}
+
+	raw, err = client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectACL(key)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectACL", KsyunKs3GoSdk)
+	}
+	addDebug("GetObjectACL", raw, requestInfo, map[string]string{"objectKey": key})
+	acl, _ := raw.(ks3.GetObjectACLResult)
+	flattenKs3ObjectCopyACL(d, acl)
+
+	return nil
+}
+
+// flattenKs3ObjectCopyHeaders sets the attributes read back from the headers of the copied object.
+func flattenKs3ObjectCopyHeaders(d *schema.ResourceData, object http.Header) error {
+	d.Set("content_type", object.Get("Content-Type"))
+	d.Set("content_length", object.Get("Content-Length"))
+	d.Set("cache_control", object.Get("Cache-Control"))
+	d.Set("content_disposition", object.Get("Content-Disposition"))
+	d.Set("content_encoding", object.Get("Content-Encoding"))
+	d.Set("expires", object.Get("Expires"))
+	d.Set("etag", strings.Trim(object.Get("ETag"), `"`))
+	d.Set("version_id", object.Get("X-Kss-Version-Id"))
+	d.Set("last_modified", object.Get(ks3.HTTPHeaderLastModified))
+	d.Set("server_side_encryption", object.Get(ks3.HTTPHeaderKs3ServerSideEncryption))
+	d.Set("kms_key_id", object.Get(ks3.HTTPHeaderKs3ServerSideEncryptionKeyID))
+	d.Set("sse_customer_key_md5", object.Get(ks3.HTTPHeaderSSECKeyMd5))
+	if storageClass := object.Get(ks3.HTTPHeaderKs3StorageClass); storageClass != "" {
+		d.Set("storage_class", storageClass)
+	} else {
+		d.Set("storage_class", string(ks3.StorageStandard))
+	}
+	return d.Set("metadata", ks3ObjectMetadata(object))
+}
+
+// flattenKs3ObjectCopyACL sets the canned ACL of the copied object. The copy only manages a canned ACL,
+// so when grants added outside of Terraform match none, the configured acl is kept instead of planning
+// a new copy on every apply.
+func flattenKs3ObjectCopyACL(d *schema.ResourceData, policy ks3.AccessControlPolicy) {
+	canned := ks3CannedACL(policy)
+	if canned == ks3ACLCustom {
+		log.Printf("[WARN] The ACL of the Ks3 object %s matches no canned ACL, keeping acl = %q", d.Id(), d.Get("acl").(string))
+		return
+	}
+	d.Set("acl", canned)
+}
+
+// ks3ObjectCopyReplaceAttributes are only sent with the REPLACE metadata directive.
+var ks3ObjectCopyReplaceAttributes = []string{
+	"content_type", "cache_control", "content_disposition", "content_encoding", "expires", "metadata",
+}
+
+// resourceKsyunKs3ObjectCopyCustomizeDiff rejects headers that would be ignored by a COPY, as the copy
+// would read back the headers of the source and plan a new copy on every apply.
+func resourceKsyunKs3ObjectCopyCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get("metadata_directive").(string) == string(ks3.MetaReplace) {
+		return nil
+	}
+	for _, key := range ks3ObjectCopyReplaceAttributes {
+		if d.HasChange(key) && d.NewValueKnown(key) {
+			return fmt.Errorf("%s can only be set with metadata_directive = %q, a COPY keeps the value of the source object",
+				key, ks3.MetaReplace)
+		}
+	}
+	return nil
+}
+
+func resourceKsyunKs3ObjectCopyUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChanges(ks3ObjectCopyAttributes...) {
+		return resourceKsyunKs3ObjectCopyCreate(d, meta)
+	}
+	return resourceKsyunKs3ObjectCopyRead(d, meta)
+}
+
+func resourceKsyunKs3ObjectCopyDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	var requestInfo *ks3.Client
+	key := d.Get("key").(string)
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return nil, bucket.DeleteObject(key)
+	})
+	if err != nil {
+		if ks3NotFoundError(err) {
+			return nil
+		}
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "DeleteObject", KsyunKs3GoSdk)
+	}
+	addDebug("DeleteObject", raw, requestInfo, map[string]string{"objectKey": key})
+	return nil
+}
+
+func buildObjectCopyOptions(d *schema.ResourceData) ([]ks3.Option, error) {
+	directive := ks3.MetadataDirectiveType(d.Get("metadata_directive").(string))
+	options := []ks3.Option{
+		ks3.MetadataDirective(directive),
+		ks3.ObjectACL(ks3.ACLType(d.Get("acl").(string))),
+	}
+	if v, ok := d.GetOk("source_version_id"); ok {
+		options = append(options, ks3.VersionId(v.(string)))
+	}
+	if v, ok := d.GetOk("storage_class"); ok {
+		options = append(options, ks3.ObjectStorageClass(ks3.StorageClassType(v.(string))))
+	}
+	if v, ok := d.GetOk("source_sse_customer_key"); ok {
+		options = append(options, ks3CopySourceSSECOptions(v.(string))...)
+	}
+	if v, ok := d.GetOk("sse_customer_key"); ok {
+		options = append(options, ks3SSECOptions(v.(string))...)
+	} else if v, ok := d.GetOk("server_side_encryption"); ok {
+		options = append(options, ks3.ServerSideEncryption(v.(string)))
+		if v.(string) == ServerSideEncryptionKMS {
+			if keyId, ok := d.GetOk("kms_key_id"); ok {
+				options = append(options, ks3.ServerSideEncryptionKeyID(keyId.(string)))
+			}
+		}
+	}
+
+	if directive != ks3.MetaReplace {
+		return options, nil
+	}
+	if v, ok := d.GetOk("content_type"); ok {
+		options = append(options, ks3.ContentType(v.(string)))
+	}
+	if v, ok := d.GetOk("cache_control"); ok {
+		options = append(options, ks3.CacheControl(v.(string)))
+	}
+	if v, ok := d.GetOk("content_disposition"); ok {
+		options = append(options, ks3.ContentDisposition(v.(string)))
+	}
+	if v, ok := d.GetOk("content_encoding"); ok {
+		options = append(options, ks3.ContentEncoding(v.(string)))
+	}
+	if v, ok := d.GetOk("expires"); ok {
+		expires, err := time.Parse(time.RFC1123, v.(string))
+		if err != nil {
+			return nil, fmt.Errorf("expires format must respect the RFC1123 standard (current value: %s)", v.(string))
+		}
+		options = append(options, ks3.Expires(expires))
+	}
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		options = append(options, ks3.Meta(k, v.(string)))
+	}
+	return options, nil
+}
+
+// ks3ObjectCopyHeaderOptions returns the content headers and user metadata of the source object as
+// options, for copies that do not go through the copy API itself.
+func ks3ObjectCopyHeaderOptions(source http.Header) []ks3.Option {
+	var options []ks3.Option
+	if v := source.Get("Content-Type"); v != "" {
+		options = append(options, ks3.ContentType(v))
+	}
+	if v := source.Get("Cache-Control"); v != "" {
+		options = append(options, ks3.CacheControl(v))
+	}
+	if v := source.Get("Content-Disposition"); v != "" {
+		options = append(options, ks3.ContentDisposition(v))
+	}
+	if v := source.Get("Content-Encoding"); v != "" {
+		options = append(options, ks3.ContentEncoding(v))
+	}
+	if expires, err := http.ParseTime(source.Get("Expires")); err == nil {
+		options = append(options, ks3.Expires(expires))
+	}
+	for k, v := range ks3ObjectMetadata(source) {
+		options = append(options, ks3.Meta(k, v))
+	}
+	return options
+}
@@ -0,0 +1,115 @@
+package ksyun
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+)
+
+func TestResourceKsyunKs3ObjectCopySchema(t *testing.T) {
+	if err := resourceKsyunKs3ObjectCopy().InternalValidate(nil, true); err != nil {
+		t.Fatalf("invalid schema: %s", err)
+	}
+}
+
+func TestResourceKsyunKs3ObjectCopyCustomizeDiff(t *testing.T) {
+	cases := []struct {
+		config map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{}, ""},
+		{map[string]interface{}{"content_type": "text/plain"}, "content_type can only be set"},
+		{map[string]interface{}{"metadata": map[string]interface{}{"owner": "ops"}}, "metadata can only be set"},
+		{map[string]interface{}{"metadata_directive": "REPLACE", "content_type": "text/plain"}, ""},
+	}
+	r := resourceKsyunKs3ObjectCopy()
+	for i, c := range cases {
+		raw := map[string]interface{}{
+			"bucket":        "my-bucket",
+			"key":           "copy.txt",
+			"source_bucket": "source-bucket",
+			"source_key":    "source.txt",
+		}
+		for k, v := range c.config {
+			raw[k] = v
+		}
+		_, err := r.Diff(nil, terraform.NewResourceConfigRaw(raw), nil)
+		if c.err == "" && err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("case %d: expected an error containing %q, got %v", i, c.err, err)
+		}
+	}
+}
+
+func TestBuildObjectCopyOptions(t *testing.T) {
+	for _, directive := range []string{string(ks3.MetaCopy), string(ks3.MetaReplace)} {
+		d := schema.TestResourceDataRaw(t, resourceKsyunKs3ObjectCopy().Schema, map[string]interface{}{
+			"bucket":             "my-bucket",
+			"key":                "copy.txt",
+			"source_bucket":      "source-bucket",
+			"source_key":         "source.txt",
+			"metadata_directive": directive,
+			"content_type":       "text/plain",
+		})
+		options, err := buildObjectCopyOptions(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, _, _ := ks3.IsOptionSet(options, ks3.HTTPHeaderContentType)
+		if set != (directive == string(ks3.MetaReplace)) {
+			t.Errorf("%s: Content-Type sent %t", directive, set)
+		}
+	}
+}
+
+func TestFlattenKs3ObjectCopyHeaders(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceKsyunKs3ObjectCopy().Schema, map[string]interface{}{})
+	header := http.Header{}
+	header.Set("Content-Type", "text/plain")
+	header.Set("Content-Length", "11")
+	header.Set("ETag", `"5eb63bbbe01eeed093cb22bb8f5acdc3"`)
+	header.Set("X-Kss-Meta-Owner", "ops")
+	if err := flattenKs3ObjectCopyHeaders(d, header); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"content_type":   "text/plain",
+		"content_length": "11",
+		"etag":           "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		"storage_class":  string(ks3.StorageStandard),
+	}
+	for k, v := range expected {
+		if got := d.Get(k); got != v {
+			t.Errorf("expected %s = %v, got %v", k, v, got)
+		}
+	}
+	if metadata := d.Get("metadata").(map[string]interface{}); len(metadata) != 1 {
+		t.Errorf("unexpected metadata %v", metadata)
+	}
+}
+
+func TestFlattenKs3ObjectCopyACL(t *testing.T) {
+	publicRead := ks3.Grant{Grantee: ks3.Grantee{Uri: ks3.ALL_USERS}, Permission: ks3.PermissionRead}
+	userRead := ks3.Grant{Grantee: ks3.Grantee{ID: "12345"}, Permission: ks3.PermissionRead}
+	cases := []struct {
+		grants   []ks3.Grant
+		expected string
+	}{
+		{[]ks3.Grant{publicRead}, "public-read"},
+		// Grants added outside of Terraform keep the configured acl.
+		{[]ks3.Grant{userRead}, "private"},
+	}
+	for i, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceKsyunKs3ObjectCopy().Schema, map[string]interface{}{"acl": "private"})
+		flattenKs3ObjectCopyACL(d, ks3.AccessControlPolicy{ACL: c.grants})
+		if acl := d.Get("acl").(string); acl != c.expected {
+			t.Errorf("case %d: expected acl %q, got %q", i, c.expected, acl)
+		}
+	}
+}
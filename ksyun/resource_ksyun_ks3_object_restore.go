@@ -0,0 +1,235 @@
+package ksyun
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
+	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ks3HTTPHeaderRestore = "X-Kss-Restore"
+
+	ks3RestoreOngoing  = "ongoing"
+	ks3RestoreRestored = "restored"
+)
+
+var ks3RestoreHeaderRegexp = regexp.MustCompile(`([a-z-]+)="([^"]*)"`)
+
+// resourceKsyunKs3ObjectRestore restores a temporary readable copy of an archived object. Destroying the
+// resource only forgets the restore, the copy expires on its own after the restore days.
+func resourceKsyunKs3ObjectRestore() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceKsyunKs3ObjectRestoreCreate,
+		Read:   resourceKsyunKs3ObjectRestoreRead,
+		Update: resourceKsyunKs3ObjectRestoreUpdate,
+		Delete: resourceKsyunKs3ObjectRestoreDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(12 * time.Hour),
+			Update: schema.DefaultTimeout(12 * time.Hour),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"key": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"version_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"days": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntBetween(1, 365),
+			},
+
+			// Computed values
+			"storage_class": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"restore_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"expiry_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceKsyunKs3ObjectRestoreCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	key := d.Get("key").(string)
+	id := ks3BucketObjectId(d.Get("bucket").(string), key)
+
+	object, err := describeKs3ObjectRestore(client, d)
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, id, "GetObjectDetailedMeta", KsyunKs3GoSdk)
+	}
+	if !isKs3ArchiveStorageClass(object.Get(ks3.HTTPHeaderKs3StorageClass)) {
+		return WrapError(Error("The Ks3 object %s has the storage class %q, only archived objects can be restored",
+			id, object.Get(ks3.HTTPHeaderKs3StorageClass)))
+	}
+
+	if err := restoreKs3Object(client, d, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+	d.SetId(id)
+	return resourceKsyunKs3ObjectRestoreRead(d, meta)
+}
+
+func resourceKsyunKs3ObjectRestoreRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	object, err := describeKs3ObjectRestore(client, d)
+	if err != nil {
+		if ks3NotFoundError(err) {
+			log.Printf("[WARN] Ks3 object %s not found, removing the restore from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return WrapErrorf(err, DefaultErrorMsg, d.Id(), "GetObjectDetailedMeta", KsyunKs3GoSdk)
+	}
+
+	status, expiry := parseKs3RestoreHeader(object.Get(ks3HTTPHeaderRestore))
+	if status == "" {
+		log.Printf("[WARN] The restored copy of the Ks3 object %s has expired, removing the restore from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	d.Set("storage_class", object.Get(ks3.HTTPHeaderKs3StorageClass))
+	d.Set("restore_status", status)
+	d.Set("expiry_date", expiry)
+	return nil
+}
+
+func resourceKsyunKs3ObjectRestoreUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*connectivity.KsyunClient)
+	// Restoring an object again sets the number of days the restored copy is kept for.
+	if d.HasChange("days") {
+		if err := restoreKs3Object(client, d, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+	return resourceKsyunKs3ObjectRestoreRead(d, meta)
+}
+
+func resourceKsyunKs3ObjectRestoreDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] The restored copy of the Ks3 object %s is kept until %s", d.Id(), d.Get("expiry_date").(string))
+	return nil
+}
+
+// restoreKs3Object requests the restore and waits until the restored copy can be read.
+func restoreKs3Object(client *connectivity.KsyunClient, d *schema.ResourceData, timeout time.Duration) error {
+	key := d.Get("key").(string)
+	id := ks3BucketObjectId(d.Get("bucket").(string), key)
+	var options []ks3.Option
+	if v, ok := d.GetOk("version_id"); ok {
+		options = append(options, ks3.VersionId(v.(string)))
+	}
+	config := ks3.RestoreConfiguration{Days: int64(d.Get("days").(int))}
+
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return nil, bucket.RestoreObjectDetail(key, config, options...)
+	})
+	if err != nil && !IsExpectedErrors(err, []string{"RestoreAlreadyInProgress"}) {
+		return WrapErrorf(err, DefaultErrorMsg, id, "RestoreObject", KsyunKs3GoSdk)
+	}
+	addDebug("RestoreObject", raw, requestInfo, map[string]interface{}{
+		"objectKey": key,
+		"days":      config.Days,
+	})
+
+	conf := &ks3WaitConf{
+		Pending:      []Status{ks3RestoreOngoing},
+		Target:       []Status{ks3RestoreRestored},
+		Timeout:      timeout,
+		Delay:        10 * time.Second,
+		PollInterval: time.Minute,
+		Refresh: func() (interface{}, Status, error) {
+			object, err := describeKs3ObjectRestore(client, d)
+			if err != nil {
+				return nil, "", err
+			}
+			status, _ := parseKs3RestoreHeader(object.Get(ks3HTTPHeaderRestore))
+			if status == "" {
+				// The restore header can lag behind the request.
+				status = ks3RestoreOngoing
+			}
+			return object, Status(status), nil
+		},
+	}
+	_, err = conf.WaitForStatus(id)
+	return err
+}
+
+func describeKs3ObjectRestore(client *connectivity.KsyunClient, d *schema.ResourceData) (http.Header, error) {
+	key := d.Get("key").(string)
+	var options []ks3.Option
+	if v, ok := d.GetOk("version_id"); ok {
+		options = append(options, ks3.VersionId(v.(string)))
+	}
+	var requestInfo *ks3.Client
+	raw, err := client.WithKs3BucketByName(d.Get("bucket").(string), func(bucket *ks3.Bucket) (interface{}, error) {
+		requestInfo = &bucket.Client
+		return bucket.GetObjectDetailedMeta(key, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	addDebug("GetObjectDetailedMeta", raw, requestInfo, map[string]string{"objectKey": key})
+	object, _ := raw.(http.Header)
+	return object, nil
+}
+
+// parseKs3RestoreHeader parses a header such as `ongoing-request="false", expiry-date="..."` into the
+// restore status and expiry date. The status is empty when the object has no restore.
+func parseKs3RestoreHeader(header string) (status, expiry string) {
+	if header == "" {
+		return "", ""
+	}
+	status = ks3RestoreOngoing
+	for _, match := range ks3RestoreHeaderRegexp.FindAllStringSubmatch(header, -1) {
+		switch match[1] {
+		case "ongoing-request":
+			if match[2] == "false" {
+				status = ks3RestoreRestored
+			}
+		case "expiry-date":
+			expiry = match[2]
+		}
+	}
+	return status, expiry
+}
+
+func isKs3ArchiveStorageClass(storageClass string) bool {
+	for _, class := range []ks3.StorageClassType{ks3.StorageArchive, ks3.StorageDeepIA, ks3.StorageDeepColdArchive} {
+		if strings.EqualFold(storageClass, string(class)) {
+			return true
+		}
+	}
+	return false
+}
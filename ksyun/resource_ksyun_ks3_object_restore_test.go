@@ -0,0 +1,29 @@
+package ksyun
+
+import "testing"
+
+func TestParseKs3RestoreHeader(t *testing.T) {
+	cases := []struct {
+		header string
+		status string
+		expiry string
+	}{
+		{"", "", ""},
+		{`ongoing-request="true"`, ks3RestoreOngoing, ""},
+		{`ongoing-request="false", expiry-date="Sun, 16 Apr 2017 08:12:33 GMT"`, ks3RestoreRestored, "Sun, 16 Apr 2017 08:12:33 GMT"},
+	}
+	for _, c := range cases {
+		status, expiry := parseKs3RestoreHeader(c.header)
+		if status != c.status || expiry != c.expiry {
+			t.Errorf("parseKs3RestoreHeader(%q) = %q, %q, expected %q, %q", c.header, status, expiry, c.status, c.expiry)
+		}
+	}
+}
+
+func TestIsKs3ArchiveStorageClass(t *testing.T) {
+	for class, archived := range map[string]bool{"ARCHIVE": true, "Archive": true, "DEEP_IA": true, "STANDARD": false, "": false} {
+		if got := isKs3ArchiveStorageClass(class); got != archived {
+			t.Errorf("isKs3ArchiveStorageClass(%q) = %t, expected %t", class, got, archived)
+		}
+	}
+}
@@ -56,8 +56,12 @@ type resourceAttr struct {
 }
 
 var ks3BucketBasicMap = map[string]string{
-	"creation_date":    CHECKSET,
-	"lifecycle_rule.#": "0",
+	"creation_date":      CHECKSET,
+	"location":           CHECKSET,
+	"owner":              CHECKSET,
+	"extranet_endpoint":  CHECKSET,
+	"bucket_domain_name": CHECKSET,
+	"lifecycle_rule.#":   "0",
 }
 
 // check the existence of resource
@@ -1,9 +1,14 @@
 package ksyun
 
 import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/wilac-pv/ksyun-ks3-go-sdk/ks3"
 	"github.com/wilac-pv/terraform-provider-ks3/ksyun/connectivity"
-	"strconv"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -31,23 +36,335 @@ func (s *Ks3Service) DescribeKs3Bucket(id string) (response ks3.GetBucketInfoRes
 	return
 }
 
-func (s *Ks3Service) WaitForKs3BucketObject(bucket *ks3.Bucket, id string, status Status, timeout int) error {
-	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+// ListKs3BucketObjectKeys returns at most limit keys of the objects stored in the bucket.
+func (s *Ks3Service) ListKs3BucketObjectKeys(bucketName string, limit int) (keys []string, err error) {
+	request := map[string]interface{}{"bucketName": bucketName, "maxKeys": limit}
+	raw, err := s.client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+		return bucket.ListObjects(ks3.MaxKeys(limit))
+	})
+	if err != nil {
+		return keys, WrapErrorf(err, DefaultErrorMsg, bucketName, "ListObjects", KsyunKs3GoSdk)
+	}
+	addDebug("ListObjects", raw, request)
+	response, _ := raw.(ks3.ListObjectsResult)
+	for _, object := range response.Objects {
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+// The multi-object delete API accepts at most 1000 keys per request.
+const ks3DeleteObjectsBatchSize = 1000
+const ks3DeleteObjectsWorkers = 8
+const ks3DeleteObjectsRetryCount = 3
+
+// EmptyKs3Bucket deletes all objects, object versions, delete markers and incomplete multipart
+// uploads of the bucket. Objects are removed with multi-object deletes spread across a bounded
+// worker pool. A failed deletion does not stop the others; all failures are reported together
+// once every item has been visited.
+func (s *Ks3Service) EmptyKs3Bucket(bucketName string) error {
+	raw, err := s.client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		return ks3Client.Bucket(bucketName)
+	})
+	if err != nil {
+		return WrapErrorf(err, DefaultErrorMsg, bucketName, "Bucket", KsyunKs3GoSdk)
+	}
+	bucket, _ := raw.(*ks3.Bucket)
+
+	var mutex sync.Mutex
+	var failures []string
+	var failed, deleted int64
+	var wg sync.WaitGroup
+	batches := make(chan []ks3.DeleteObject)
+	for i := 0; i < ks3DeleteObjectsWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				batchFailures := deleteKs3ObjectBatch(bucket, batch)
+				total := atomic.AddInt64(&deleted, int64(len(batch)-len(batchFailures)))
+				log.Printf("[INFO] Emptying the KS3 bucket %s: %d item(s) deleted so far", bucketName, total)
+				if len(batchFailures) == 0 {
+					continue
+				}
+				mutex.Lock()
+				failed += int64(len(batchFailures))
+				for _, failure := range batchFailures {
+					if len(failures) < 20 {
+						failures = append(failures, failure)
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	err = s.listKs3BucketDeletableObjects(bucketName, batches)
+	close(batches)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Emptying the KS3 bucket %s: %d item(s) deleted, %d failed", bucketName, deleted, failed)
+
+	keyMarker, uploadIdMarker := "", ""
 	for {
-		exist, err := bucket.IsObjectExist(id)
+		raw, err := s.client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+			return bucket.ListMultipartUploads(ks3.KeyMarker(keyMarker), ks3.UploadIDMarker(uploadIdMarker))
+		})
 		if err != nil {
-			return WrapErrorf(err, DefaultErrorMsg, id, "IsObjectExist", KsyunKs3GoSdk)
+			return WrapErrorf(err, DefaultErrorMsg, bucketName, "ListMultipartUploads", KsyunKs3GoSdk)
 		}
-		addDebug("IsObjectExist", exist)
+		response, _ := raw.(ks3.ListMultipartUploadResult)
+		for _, upload := range response.Uploads {
+			imur := ks3.InitiateMultipartUploadResult{Bucket: bucketName, Key: upload.Key, UploadID: upload.UploadID}
+			if err := bucket.AbortMultipartUpload(imur); err != nil && !ks3NotFoundError(err) {
+				failed++
+				if len(failures) < 20 {
+					failures = append(failures, fmt.Sprintf("%s (upload %s): %s", upload.Key, upload.UploadID, err))
+				}
+			}
+		}
+		if !response.IsTruncated {
+			break
+		}
+		keyMarker, uploadIdMarker = response.NextKeyMarker, response.NextUploadIDMarker
+	}
 
-		if !exist {
-			return nil
+	if failed > 0 {
+		return WrapError(Error("Failed to delete %d item(s) from the bucket %s, including:\n%s", failed, bucketName, strings.Join(failures, "\n")))
+	}
+	return nil
+}
+
+// listKs3BucketDeletableObjects sends every object, object version and delete marker of the bucket
+// to batches, at most ks3DeleteObjectsBatchSize items at a time.
+func (s *Ks3Service) listKs3BucketDeletableObjects(bucketName string, batches chan<- []ks3.DeleteObject) error {
+	marker := ""
+	for {
+		raw, err := s.client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+			return bucket.ListObjects(ks3.Marker(marker), ks3.MaxKeys(ks3DeleteObjectsBatchSize))
+		})
+		if err != nil {
+			return WrapErrorf(err, DefaultErrorMsg, bucketName, "ListObjects", KsyunKs3GoSdk)
+		}
+		response, _ := raw.(ks3.ListObjectsResult)
+		if len(response.Objects) == 0 {
+			break
+		}
+		batch := make([]ks3.DeleteObject, 0, len(response.Objects))
+		for _, object := range response.Objects {
+			batch = append(batch, ks3.DeleteObject{Key: object.Key})
+		}
+		batches <- batch
+		if !response.IsTruncated {
+			break
+		}
+		marker = response.NextMarker
+		if marker == "" {
+			marker = response.Objects[len(response.Objects)-1].Key
+		}
+	}
+
+	raw, err := s.client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+		return ks3Client.GetBucketVersioning(bucketName)
+	})
+	if err != nil && !ks3NotFoundError(err) {
+		return WrapErrorf(err, DefaultErrorMsg, bucketName, "GetBucketVersioning", KsyunKs3GoSdk)
+	}
+	if versioning, _ := raw.(ks3.GetBucketVersioningResult); versioning.Status == "" {
+		return nil
+	}
+
+	keyMarker, versionIdMarker := "", ""
+	for {
+		raw, err := s.client.WithKs3BucketByName(bucketName, func(bucket *ks3.Bucket) (interface{}, error) {
+			return bucket.ListObjectVersions(ks3.KeyMarker(keyMarker), ks3.VersionIdMarker(versionIdMarker), ks3.MaxKeys(ks3DeleteObjectsBatchSize))
+		})
+		if err != nil {
+			return WrapErrorf(err, DefaultErrorMsg, bucketName, "ListObjectVersions", KsyunKs3GoSdk)
+		}
+		response, _ := raw.(ks3.ListObjectVersionsResult)
+		batch := make([]ks3.DeleteObject, 0, len(response.ObjectVersions)+len(response.ObjectDeleteMarkers))
+		for _, version := range response.ObjectVersions {
+			batch = append(batch, ks3.DeleteObject{Key: version.Key, VersionId: version.VersionId})
+		}
+		for _, deleteMarker := range response.ObjectDeleteMarkers {
+			batch = append(batch, ks3.DeleteObject{Key: deleteMarker.Key, VersionId: deleteMarker.VersionId})
+		}
+		// A page may hold up to MaxKeys versions plus delete markers, so split it to respect the batch limit.
+		for len(batch) > 0 {
+			size := len(batch)
+			if size > ks3DeleteObjectsBatchSize {
+				size = ks3DeleteObjectsBatchSize
+			}
+			batches <- batch[:size]
+			batch = batch[size:]
+		}
+		if !response.IsTruncated {
+			break
+		}
+		keyMarker, versionIdMarker = response.NextKeyMarker, response.NextVersionIdMarker
+	}
+	return nil
+}
+
+// deleteKs3ObjectBatch deletes the objects with one multi-object delete request and returns a
+// description of every object that was not deleted.
+func deleteKs3ObjectBatch(bucket *ks3.Bucket, batch []ks3.DeleteObject) (failures []string) {
+	var result ks3.DeleteObjectVersionsResult
+	var err error
+	for retry := 0; retry < ks3DeleteObjectsRetryCount; retry++ {
+		result, err = bucket.DeleteObjectVersions(batch)
+		if err == nil {
+			break
+		}
+		time.Sleep(time.Duration(retry+1) * time.Second)
+	}
+	if err != nil {
+		for _, object := range batch {
+			failures = append(failures, fmt.Sprintf("%s: %s", describeKs3DeleteObject(object), err))
+		}
+		return failures
+	}
+
+	deleted := make(map[string]bool, len(result.DeletedObjectsDetail))
+	for _, detail := range result.DeletedObjectsDetail {
+		deleted[detail.Key] = true
+		deleted[detail.Key+"\x00"+detail.VersionId] = true
+	}
+	for _, object := range batch {
+		id := object.Key
+		if object.VersionId != "" {
+			id += "\x00" + object.VersionId
+		}
+		if !deleted[id] {
+			failures = append(failures, fmt.Sprintf("%s: not reported as deleted", describeKs3DeleteObject(object)))
+		}
+	}
+	return failures
+}
+
+func describeKs3DeleteObject(object ks3.DeleteObject) string {
+	if object.VersionId == "" {
+		return object.Key
+	}
+	return fmt.Sprintf("%s (version %s)", object.Key, object.VersionId)
+}
+
+// ks3WaitConf waits until a KS3 resource reaches one of the target statuses. Unless PollInterval is set,
+// the interval between two checks starts at MinInterval and doubles up to 10 seconds.
+type ks3WaitConf struct {
+	Pending      []Status
+	Target       []Status
+	Refresh      func() (interface{}, Status, error)
+	Timeout      time.Duration
+	Delay        time.Duration
+	MinInterval  time.Duration
+	PollInterval time.Duration
+	// KS3 is eventually consistent, a status is only trusted once it has been seen this many times in a row.
+	ContinuousTargetOccurence int
+}
+
+func (c *ks3WaitConf) WaitForStatus(id string) (interface{}, error) {
+	stateConf := &resource.StateChangeConf{
+		Pending: ks3StatusStrings(c.Pending),
+		Target:  ks3StatusStrings(c.Target),
+		Refresh: func() (interface{}, string, error) {
+			object, status, err := c.Refresh()
+			return object, string(status), err
+		},
+		Timeout:                   c.Timeout,
+		Delay:                     c.Delay,
+		MinTimeout:                c.MinInterval,
+		PollInterval:              c.PollInterval,
+		ContinuousTargetOccurence: c.ContinuousTargetOccurence,
+	}
+	object, err := stateConf.WaitForState()
+	if err != nil {
+		if e, ok := err.(*resource.TimeoutError); ok {
+			return nil, WrapErrorf(err, WaitTimeoutMsg, id, GetFunc(2), int(c.Timeout.Seconds()), e.LastState,
+				strings.Join(e.ExpectedState, ","), ProviderERROR)
+		}
+		return nil, WrapErrorf(err, DefaultErrorMsg, id, "WaitForState", ProviderERROR)
+	}
+	return object, nil
+}
+
+func ks3StatusStrings(statuses []Status) []string {
+	result := make([]string, len(statuses))
+	for i, status := range statuses {
+		result[i] = string(status)
+	}
+	return result
+}
+
+// ks3OtherStatus returns the statuses that are pending while waiting for the target.
+func ks3OtherStatus(target Status) []Status {
+	if target == Deleted {
+		return []Status{Available}
+	}
+	return []Status{Deleted}
+}
+
+// Ks3BucketStateRefreshFunc reports whether the bucket is Available or Deleted. It sends a HEAD request
+// rather than listing buckets, so it also waits out the 404s that follow the creation of a bucket.
+func (s *Ks3Service) Ks3BucketStateRefreshFunc(id string) func() (interface{}, Status, error) {
+	return func() (interface{}, Status, error) {
+		var requestInfo *ks3.Client
+		raw, err := s.client.WithKs3Client(func(ks3Client *ks3.Client) (interface{}, error) {
+			requestInfo = ks3Client
+			return ks3Client.HeadBucket(id)
+		})
+		if err != nil {
+			if ks3NotFoundError(err) {
+				return raw, Deleted, nil
+			}
+			return nil, "", WrapErrorf(err, DefaultErrorMsg, id, "HeadBucket", KsyunKs3GoSdk)
 		}
+		addDebug("HeadBucket", raw, requestInfo, map[string]string{"bucketName": id})
+		return raw, Available, nil
+	}
+}
 
-		if time.Now().After(deadline) {
-			return WrapErrorf(err, WaitTimeoutMsg, id, GetFunc(1), timeout, strconv.FormatBool(exist), status, ProviderERROR)
+// Ks3BucketObjectStateRefreshFunc reports whether the object is Available or Deleted.
+func (s *Ks3Service) Ks3BucketObjectStateRefreshFunc(bucket *ks3.Bucket, key string) func() (interface{}, Status, error) {
+	return func() (interface{}, Status, error) {
+		exist, err := bucket.IsObjectExist(key)
+		if err != nil {
+			return nil, "", WrapErrorf(err, DefaultErrorMsg, key, "IsObjectExist", KsyunKs3GoSdk)
+		}
+		addDebug("IsObjectExist", exist)
+		if !exist {
+			return exist, Deleted, nil
 		}
+		return exist, Available, nil
+	}
+}
+
+func (s *Ks3Service) WaitForKs3Bucket(id string, status Status, timeout int) error {
+	conf := &ks3WaitConf{
+		Pending:                   ks3OtherStatus(status),
+		Target:                    []Status{status},
+		Refresh:                   s.Ks3BucketStateRefreshFunc(id),
+		Timeout:                   time.Duration(timeout) * time.Second,
+		MinInterval:               time.Second,
+		ContinuousTargetOccurence: 2,
+	}
+	_, err := conf.WaitForStatus(id)
+	return err
+}
+
+func (s *Ks3Service) WaitForKs3BucketObject(bucket *ks3.Bucket, id string, status Status, timeout int) error {
+	conf := &ks3WaitConf{
+		Pending:                   ks3OtherStatus(status),
+		Target:                    []Status{status},
+		Refresh:                   s.Ks3BucketObjectStateRefreshFunc(bucket, id),
+		Timeout:                   time.Duration(timeout) * time.Second,
+		MinInterval:               time.Second,
+		ContinuousTargetOccurence: 2,
 	}
+	_, err := conf.WaitForStatus(ks3BucketObjectId(bucket.BucketName, id))
+	return err
 }
 
 func (s *Ks3Service) DescribeKs3BucketReplication(id string) (response string, err error) {
@@ -0,0 +1,51 @@
+package ksyun
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKs3WaitConfWaitForStatus(t *testing.T) {
+	statuses := []Status{Available, Available, Deleted, Available, Deleted, Deleted}
+	calls := 0
+	conf := &ks3WaitConf{
+		Pending: []Status{Available},
+		Target:  []Status{Deleted},
+		Refresh: func() (interface{}, Status, error) {
+			status := statuses[calls]
+			calls++
+			return calls, status, nil
+		},
+		Timeout:                   time.Minute,
+		PollInterval:              time.Millisecond,
+		ContinuousTargetOccurence: 2,
+	}
+	object, err := conf.WaitForStatus("bucket/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A single Deleted between two Available does not end the wait.
+	if object.(int) != len(statuses) {
+		t.Errorf("expected the wait to end after %d checks, got %d", len(statuses), object.(int))
+	}
+}
+
+func TestKs3WaitConfWaitForStatusTimeout(t *testing.T) {
+	conf := &ks3WaitConf{
+		Pending: []Status{Available},
+		Target:  []Status{Deleted},
+		Refresh: func() (interface{}, Status, error) {
+			return true, Available, nil
+		},
+		Timeout:      50 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	}
+	_, err := conf.WaitForStatus("bucket/key")
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !strings.Contains(err.Error(), "Got: Available Expected: Deleted") {
+		t.Errorf("unexpected error %s", err)
+	}
+}
@@ -1,12 +1,22 @@
 package ksyun
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 	"time"
 )
 
 const Iso8601DateFormat = "2006-01-02T00:00:00+08:00"
 
+// The unique suffix appended by resource.PrefixedUniqueId is 26 characters long.
+const ks3BucketPrefixMaxLength = 63 - 26
+
+var ks3BucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
+var ks3BucketPrefixRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
 func validateKs3BucketDateTimestamp(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(string)
 	_, err := time.Parse(Iso8601DateFormat, value)
@@ -16,3 +26,50 @@ func validateKs3BucketDateTimestamp(v interface{}, k string) (ws []string, error
 	}
 	return
 }
+
+func validateKs3BucketName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if len(value) < 3 || len(value) > 63 {
+		errors = append(errors, fmt.Errorf("%q must contain between 3 and 63 characters, got %q", k, value))
+	}
+	if !ks3BucketNameRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf(
+			"%q may only contain lowercase letters, digits and hyphens, and must not begin or end with a hyphen, got %q", k, value))
+	}
+	if net.ParseIP(value) != nil {
+		errors = append(errors, fmt.Errorf("%q must not be formatted as an IP address, got %q", k, value))
+	}
+	return
+}
+
+func validateKs3BucketPrefix(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if len(value) > ks3BucketPrefixMaxLength {
+		errors = append(errors, fmt.Errorf("%q cannot be longer than %d characters, got %q", k, ks3BucketPrefixMaxLength, value))
+	}
+	if !ks3BucketPrefixRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf(
+			"%q may only contain lowercase letters, digits and hyphens, and must not begin with a hyphen, got %q", k, value))
+	}
+	return
+}
+
+func validateKs3ObjectMetadata(v interface{}, k string) (ws []string, errors []error) {
+	for key := range v.(map[string]interface{}) {
+		if key != strings.ToLower(key) {
+			errors = append(errors, fmt.Errorf("%q keys must be lower case, as KS3 returns metadata keys in lower case, got %q", k, key))
+		}
+	}
+	return
+}
+
+// validateKs3SSECustomerKey checks that a customer provided key is a base64 encoded 256-bit key.
+func validateKs3SSECustomerKey(v interface{}, k string) (ws []string, errors []error) {
+	key, err := base64.StdEncoding.DecodeString(v.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be base64 encoded: %s", k, err))
+	} else if len(key) != 32 {
+		errors = append(errors, fmt.Errorf("%q must be a 256-bit key, got %d bits", k, len(key)*8))
+	}
+	return
+}
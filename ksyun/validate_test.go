@@ -0,0 +1,71 @@
+package ksyun
+
+import (
+	"testing"
+)
+
+func TestValidateKs3BucketName(t *testing.T) {
+	validNames := []string{
+		"abc",
+		"terraform-test-bucket",
+		"ks3-bucket-20230410000000000000000001",
+		"1bucket9",
+	}
+	for _, v := range validNames {
+		if _, errors := validateKs3BucketName(v, "bucket"); len(errors) != 0 {
+			t.Fatalf("%q should be a valid bucket name: %q", v, errors)
+		}
+	}
+
+	invalidNames := []string{
+		"ab",
+		"Uppercase-Bucket",
+		"under_score",
+		"-leading-hyphen",
+		"trailing-hyphen-",
+		"192.168.1.1",
+		"dotted.bucket",
+		"a123456789012345678901234567890123456789012345678901234567890123",
+	}
+	for _, v := range invalidNames {
+		if _, errors := validateKs3BucketName(v, "bucket"); len(errors) == 0 {
+			t.Fatalf("%q should be an invalid bucket name", v)
+		}
+	}
+}
+
+func TestValidateKs3BucketPrefix(t *testing.T) {
+	validPrefixes := []string{
+		"tf-",
+		"terraform-test-",
+		"a",
+	}
+	for _, v := range validPrefixes {
+		if _, errors := validateKs3BucketPrefix(v, "bucket_prefix"); len(errors) != 0 {
+			t.Fatalf("%q should be a valid bucket prefix: %q", v, errors)
+		}
+	}
+
+	invalidPrefixes := []string{
+		"-tf",
+		"Terraform-",
+		"tf_",
+		"a1234567890123456789012345678901234567",
+	}
+	for _, v := range invalidPrefixes {
+		if _, errors := validateKs3BucketPrefix(v, "bucket_prefix"); len(errors) == 0 {
+			t.Fatalf("%q should be an invalid bucket prefix", v)
+		}
+	}
+}
+
+func TestValidateKs3SSECustomerKey(t *testing.T) {
+	if _, errors := validateKs3SSECustomerKey("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", "sse_customer_key"); len(errors) != 0 {
+		t.Fatalf("a base64 encoded 256-bit key should be valid: %q", errors)
+	}
+	for _, v := range []string{"not base64!", "AAAAAAAAAAAAAAAAAAAAAA=="} {
+		if _, errors := validateKs3SSECustomerKey(v, "sse_customer_key"); len(errors) == 0 {
+			t.Fatalf("%q should be an invalid customer key", v)
+		}
+	}
+}